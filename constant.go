@@ -50,6 +50,8 @@ const (
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + CharsetUTF8
 	MIMEApplicationXML                   = "application/xml"
 	MIMEApplicationXMLCharsetUTF8        = MIMEApplicationXML + "; " + CharsetUTF8
+	MIMEApplicationProblemJSON           = "application/problem+json"
+	MIMEApplicationProblemXML            = "application/problem+xml"
 	MIMEApplicationForm                  = "application/x-www-form-urlencoded"
 	MIMEApplicationProtobuf              = "application/protobuf"
 	MIMEApplicationMsgpack               = "application/msgpack"
@@ -84,6 +86,11 @@ const (
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
+	HeaderETag                = "ETag"
+	HeaderRange               = "Range"
+	HeaderContentRange        = "Content-Range"
+	HeaderAcceptRanges        = "Accept-Ranges"
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
 	HeaderLastModified        = "Last-Modified"
@@ -105,6 +112,13 @@ const (
 	CacheControlPrefix        = "public, max-age="
 	HeaderConnection          = "Connection"
 	HeaderTransferEncoding    = "Transfer-Encoding"
+	HeaderReferer             = "Referer"
+
+	// Fetch Metadata
+	HeaderSecFetchSite = "Sec-Fetch-Site"
+	HeaderSecFetchMode = "Sec-Fetch-Mode"
+	HeaderSecFetchDest = "Sec-Fetch-Dest"
+	HeaderSecFetchUser = "Sec-Fetch-User"
 
 	// Access control
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
@@ -116,13 +130,24 @@ const (
 	HeaderAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
 	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
 
+	// Private Network Access
+	HeaderAccessControlRequestPrivateNetwork = "Access-Control-Request-Private-Network"
+	HeaderAccessControlAllowPrivateNetwork   = "Access-Control-Allow-Private-Network"
+
 	// Security
-	HeaderStrictTransportSecurity = "Strict-Transport-Security"
-	HeaderXContentTypeOptions     = "X-Content-Type-Options"
-	HeaderXXSSProtection          = "X-XSS-Protection"
-	HeaderXFrameOptions           = "X-Frame-Options"
-	HeaderContentSecurityPolicy   = "Content-Security-Policy"
-	HeaderXCSRFToken              = "X-CSRF-Token"
+	HeaderStrictTransportSecurity         = "Strict-Transport-Security"
+	HeaderXContentTypeOptions             = "X-Content-Type-Options"
+	HeaderXXSSProtection                  = "X-XSS-Protection"
+	HeaderXFrameOptions                   = "X-Frame-Options"
+	HeaderContentSecurityPolicy           = "Content-Security-Policy"
+	HeaderContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
+	HeaderPermissionsPolicy               = "Permissions-Policy"
+	HeaderCrossOriginOpenerPolicy         = "Cross-Origin-Opener-Policy"
+	HeaderCrossOriginEmbedderPolicy       = "Cross-Origin-Embedder-Policy"
+	HeaderCrossOriginResourcePolicy       = "Cross-Origin-Resource-Policy"
+	HeaderReferrerPolicy                  = "Referrer-Policy"
+	HeaderReportingEndpoints              = "Reporting-Endpoints"
+	HeaderXCSRFToken                      = "X-CSRF-Token"
 
 	FilePathSeparator = string(filepath.Separator)
 
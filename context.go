@@ -15,6 +15,25 @@ import (
 	"github.com/webx-top/echo/param"
 )
 
+// RequestIDer is implemented by the contexts which carry the ID of the request.
+// The `Context` created by Echo implements it.
+type RequestIDer interface {
+	SetLogger(logger.Logger)
+	RequestID() string
+	SetRequestID(string)
+}
+
+var _ RequestIDer = &xContext{}
+
+// RequestIDOf returns the ID of the request, or an empty string if the
+// context does not implement `RequestIDer`.
+func RequestIDOf(c Context) string {
+	if r, ok := c.(RequestIDer); ok {
+		return r.RequestID()
+	}
+	return ``
+}
+
 // Context represents context for the current request. It holds request and
 // response objects, path parameters, data and registered handler.
 type Context interface {
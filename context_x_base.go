@@ -50,6 +50,8 @@ type xContext struct {
 	auto                bool
 	onHostFound         func(Context) (bool, error)
 	realIP              string
+	logger              logger.Logger
+	requestID           string
 }
 
 var _ context.Context = (*xContext)(nil)
@@ -166,11 +168,33 @@ func (c *xContext) NewErrorWith(err error, code pkgCode.Code, args ...interface{
 	return NewErrorWith(err, msg, code).NoClone()
 }
 
-// Logger returns the `Logger` instance.
+// Logger returns the `Logger` instance of the current request,
+// which is the logger of Echo unless it is set by `SetLogger` or `SetRequestID`.
 func (c *xContext) Logger() logger.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
 	return c.echo.logger
 }
 
+// SetLogger sets the logger of the current request.
+func (c *xContext) SetLogger(l logger.Logger) {
+	c.logger = l
+}
+
+// RequestID returns the ID of the current request.
+func (c *xContext) RequestID() string {
+	return c.requestID
+}
+
+// SetRequestID sets the ID of the current request, which is added as
+// the `request_id` field to the entries of `Logger()`.
+// The logger of Echo is wrapped, so that calling it again replaces the field.
+func (c *xContext) SetRequestID(id string) {
+	c.requestID = id
+	c.logger = logger.WithFields(c.echo.logger, logger.Fields{`request_id`: id})
+}
+
 // Object returns the `context` object.
 func (c *xContext) Object() *xContext {
 	return c
@@ -232,6 +256,8 @@ func (c *xContext) Reset(req engine.Request, res engine.Response) {
 	c.renderDataWrapper = c.echo.renderDataWrapper
 	c.ResetFuncs(c.echo.FuncMap)
 	c.realIP = ""
+	c.logger = nil
+	c.requestID = ""
 	// NOTE: Don't reset because it has to have length c.echo.maxParam at all times
 	for i := 0; i < *c.echo.maxParam; i++ {
 		c.pvalues[i] = ""
@@ -279,6 +305,7 @@ func (c *xContext) Fetch(name string, data interface{}) (b []byte, err error) {
 	if c.renderDataWrapper != nil {
 		data = c.renderDataWrapper(c, data)
 	}
+	c.callRenderHook(name, data)
 	err = c.renderer.Render(buf, name, data, c)
 	if err != nil {
 		return
@@ -287,6 +314,12 @@ func (c *xContext) Fetch(name string, data interface{}) (b []byte, err error) {
 	return
 }
 
+func (c *xContext) callRenderHook(name string, data interface{}) {
+	if hook, ok := c.request.Context().Value(RenderHookKey).(RenderHook); ok {
+		hook(name, data)
+	}
+}
+
 func (c *xContext) Validate(item interface{}, args ...interface{}) error {
 	return Validate(c, item, args...)
 }
@@ -430,7 +430,12 @@ func (c *xContext) RealIP() string {
 	if len(c.realIP) > 0 {
 		return c.realIP
 	}
-	c.realIP = c.echo.RealIPConfig().ClientIP(c.Request().RemoteAddress(), c.Header)
+	remoteAddress := c.Request().RemoteAddress()
+	if engine.IsUnixRemoteAddress(remoteAddress) {
+		// unix 域套接字的客户端在本机，按回环地址判断是否信任代理头
+		remoteAddress = `127.0.0.1`
+	}
+	c.realIP = c.echo.RealIPConfig().ClientIP(remoteAddress, c.Header)
 	return c.realIP
 }
 
@@ -59,6 +59,7 @@ func (c *xContext) RenderBy(name string, content func(string) ([]byte, error), d
 	if c.renderDataWrapper != nil {
 		data = c.renderDataWrapper(c, data)
 	}
+	c.callRenderHook(name, data)
 	err = c.renderer.RenderBy(buf, name, content, data, c)
 	if err != nil {
 		return
@@ -157,6 +157,14 @@ func (d *RawData) SetError(err error, args ...int) Data {
 		d.SetCode(pkgCode.Failure.Int())
 		d.Info = err.Error()
 	}
+	if fields := FieldErrorsOf(err); len(fields) > 0 {
+		if d.Zone == nil {
+			d.Zone = fields[0].Field
+		}
+		if d.Data == nil {
+			d.Data = fields.Map() // 所有验证失败的字段及其错误信息
+		}
+	}
 	if len(args) > 0 {
 		d.SetCode(args[0])
 	}
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/admpub/log"
 	"github.com/admpub/realip"
@@ -87,8 +88,16 @@ type (
 		Render(w io.Writer, name string, data interface{}, c Context) error
 		RenderBy(w io.Writer, name string, content func(string) ([]byte, error), data interface{}, c Context) error
 	}
+
+	// RenderHook is called before rendering each template of a request whose value
+	// `RenderHookKey` is set to it (see `engine.Request.SetValue`), e.g. by the test
+	// client to record the rendered templates without replacing the renderer.
+	RenderHook func(name string, data interface{})
 )
 
+// RenderHookKey is the key of the `RenderHook` in the values of a request.
+const RenderHookKey = `echo.renderHook`
+
 func (m MiddlewareFunc) Handle(h Handler) Handler {
 	return m(h)
 }
@@ -886,6 +895,15 @@ func (e *Echo) Shutdown(ctx context.Context) error {
 	return e.engine.Shutdown(ctx)
 }
 
+// Restart starts a new process of the program which inherits the listener, and then
+// shuts down the current server gracefully within timeout. See `engine.Restart`.
+func (e *Echo) Restart(timeout time.Duration) error {
+	if e.engine == nil {
+		return nil
+	}
+	return engine.Restart(e.engine, timeout)
+}
+
 func (e *Echo) findRouter(host string) (*Router, []string, []string, bool) {
 	if len(e.hosts) == 0 {
 		return e.router, nil, nil, false
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	. "github.com/webx-top/echo"
+	"github.com/webx-top/echo/logger"
 	mw "github.com/webx-top/echo/middleware"
 	test "github.com/webx-top/echo/testing"
 )
@@ -299,3 +300,33 @@ func TestEchoData(t *testing.T) {
 	assert.Equal(t, `Failure`, fmt.Sprintf(`%v`, data.Code))
 	assert.Equal(t, `Failure`, data.State)
 }
+
+type fieldsRecorder struct {
+	logger.Base
+	fields []string
+}
+
+func (f *fieldsRecorder) WithFields(fields logger.Fields) logger.Logger {
+	r := &fieldsRecorder{fields: append([]string{}, f.fields...)}
+	for k, v := range fields {
+		r.fields = append(r.fields, k+`=`+fmt.Sprint(v))
+	}
+	return r
+}
+
+func TestContextSetRequestID(t *testing.T) {
+	e := New()
+	e.SetLogger(&fieldsRecorder{})
+	e.Get(`/`, func(c Context) error {
+		r, ok := c.(RequestIDer)
+		assert.True(t, ok)
+		r.SetRequestID(`a`)
+		r.SetRequestID(`b`)
+		assert.Equal(t, `b`, RequestIDOf(c))
+		assert.Equal(t, []string{`request_id=b`}, c.Logger().(*fieldsRecorder).fields)
+		return c.String(`OK`)
+	})
+	e.Commit()
+	code, _ := request(GET, `/`, e)
+	assert.Equal(t, http.StatusOK, code)
+}
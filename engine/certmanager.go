@@ -0,0 +1,298 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/admpub/fsnotify"
+	"github.com/admpub/log"
+
+	"github.com/webx-top/echo/logger"
+)
+
+// DefaultCertReloadDelay is the delay between the last file change and the reload,
+// so that a certificate and its key written one after the other are loaded together.
+var DefaultCertReloadDelay = time.Second
+
+// CertPair is the file path pair of a certificate and its private key.
+type CertPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+type certStore struct {
+	certs      []*tls.Certificate // same order as CertManager.pairs
+	nameToCert map[string]*tls.Certificate
+}
+
+// CertManager loads TLS certificates from files and reloads them without restart
+// when they change. A certificate that fails to reload keeps serving the old one.
+type CertManager struct {
+	Logger   logger.Logger
+	Delay    time.Duration
+	OnReload func(pair CertPair, err error) // called after each reload attempt of a changed pair
+
+	pairs   []CertPair
+	store   atomic.Value // *certStore
+	mutex   sync.Mutex
+	watcher *fsnotify.Watcher
+	timer   *time.Timer
+}
+
+func NewCertManager() *CertManager {
+	m := &CertManager{
+		Logger: log.GetLogger("echo"),
+		Delay:  DefaultCertReloadDelay,
+	}
+	m.store.Store(&certStore{nameToCert: map[string]*tls.Certificate{}})
+	return m
+}
+
+// Add loads the key pair and adds it to the manager.
+func (m *CertManager) Add(certFile, keyFile string) error {
+	cert, err := loadCertificate(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.pairs = append(m.pairs, CertPair{CertFile: certFile, KeyFile: keyFile})
+	old := m.store.Load().(*certStore)
+	certs := make([]*tls.Certificate, len(old.certs), len(old.certs)+1)
+	copy(certs, old.certs)
+	m.store.Store(newCertStore(append(certs, cert)))
+	if m.watcher != nil {
+		m.watch(m.pairs[len(m.pairs)-1])
+	}
+	return nil
+}
+
+// Pairs returns the file path pairs of the managed certificates.
+func (m *CertManager) Pairs() []CertPair {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]CertPair(nil), m.pairs...)
+}
+
+// Certificates returns the currently served certificates.
+func (m *CertManager) Certificates() []tls.Certificate {
+	store := m.store.Load().(*certStore)
+	certs := make([]tls.Certificate, len(store.certs))
+	for i, cert := range store.certs {
+		certs[i] = *cert
+	}
+	return certs
+}
+
+// Lookup returns the certificate matching serverName exactly or by wildcard, or nil.
+func (m *CertManager) Lookup(serverName string) *tls.Certificate {
+	store := m.store.Load().(*certStore)
+	name := strings.TrimSuffix(strings.ToLower(serverName), `.`)
+	if cert, ok := store.nameToCert[name]; ok {
+		return cert
+	}
+	if pos := strings.Index(name, `.`); pos > 0 {
+		if cert, ok := store.nameToCert[`*`+name[pos:]]; ok {
+			return cert
+		}
+	}
+	return nil
+}
+
+// GetCertificate can be used as `tls.Config.GetCertificate`.
+// It returns the first certificate when no name matches.
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := m.Lookup(hello.ServerName); cert != nil {
+		return cert, nil
+	}
+	store := m.store.Load().(*certStore)
+	if len(store.certs) == 0 {
+		return nil, errors.New(`tls: no certificates configured`)
+	}
+	return store.certs[0], nil
+}
+
+// Reload reloads all key pairs. The pairs that fail to load keep their old certificate
+// and the errors are joined in the returned error.
+func (m *CertManager) Reload() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	old := m.store.Load().(*certStore)
+	certs := make([]*tls.Certificate, len(old.certs))
+	copy(certs, old.certs)
+	var errs []error
+	var changed bool
+	for i, pair := range m.pairs {
+		cert, err := loadCertificate(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			m.Logger.Errorf(`tls: failed to reload certificate %s (keep serving the old one): %v`, pair.CertFile, err)
+			errs = append(errs, err)
+			m.onReload(pair, err)
+			continue
+		}
+		if sameCertificate(cert, certs[i]) {
+			continue
+		}
+		certs[i] = cert
+		changed = true
+		m.Logger.Infof(`tls: reloaded certificate %s (expires at %s)`, pair.CertFile, cert.Leaf.NotAfter.Format(time.RFC3339))
+		m.onReload(pair, nil)
+	}
+	if changed {
+		m.store.Store(newCertStore(certs))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *CertManager) onReload(pair CertPair, err error) {
+	if m.OnReload != nil {
+		m.OnReload(pair, err)
+	}
+}
+
+// Watch watches the directories of the key pairs and reloads them after changes.
+// Directories are watched instead of files so that replacements by rename or
+// symlink swap (certbot, Kubernetes secrets) are noticed as well; events on
+// other files in these directories are ignored.
+func (m *CertManager) Watch() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.watcher != nil {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	m.watcher = watcher
+	for _, pair := range m.pairs {
+		m.watch(pair)
+	}
+	go m.loop(watcher)
+	return nil
+}
+
+func (m *CertManager) watch(pair CertPair) {
+	for _, file := range []string{pair.CertFile, pair.KeyFile} {
+		if err := m.watcher.Add(filepath.Dir(file)); err != nil {
+			m.Logger.Errorf(`tls: failed to watch %s: %v`, file, err)
+		}
+	}
+}
+
+func (m *CertManager) loop(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod || !m.isWatched(ev.Name) {
+				continue
+			}
+			m.scheduleReload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			m.Logger.Error(`tls: certificate watcher error: `, err)
+		}
+	}
+}
+
+// kubernetesDataDir is the symlink that Kubernetes swaps atomically when a
+// mounted secret changes: the watched files are symlinks pointing into it.
+const kubernetesDataDir = `..data`
+
+// isWatched reports whether the file system event on name concerns one of the
+// certificate or key files, so that unrelated files in the same directories
+// do not trigger reloads.
+func (m *CertManager) isWatched(name string) bool {
+	name = filepath.Clean(name)
+	if filepath.Base(name) == kubernetesDataDir {
+		return true
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, pair := range m.pairs {
+		if name == filepath.Clean(pair.CertFile) || name == filepath.Clean(pair.KeyFile) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *CertManager) scheduleReload() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	m.timer = time.AfterFunc(m.Delay, func() {
+		m.Reload()
+	})
+}
+
+// Close stops watching.
+func (m *CertManager) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	if m.watcher == nil {
+		return nil
+	}
+	err := m.watcher.Close()
+	m.watcher = nil
+	return err
+}
+
+func loadCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &cert, nil
+}
+
+func sameCertificate(a, b *tls.Certificate) bool {
+	if a == nil || b == nil || len(a.Certificate) != len(b.Certificate) {
+		return false
+	}
+	for i := range a.Certificate {
+		if !bytes.Equal(a.Certificate[i], b.Certificate[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func newCertStore(certs []*tls.Certificate) *certStore {
+	store := &certStore{certs: certs, nameToCert: map[string]*tls.Certificate{}}
+	// the first certificate wins when several ones have the same name
+	for i := len(certs) - 1; i >= 0; i-- {
+		leaf := certs[i].Leaf
+		if len(leaf.Subject.CommonName) > 0 {
+			store.nameToCert[strings.ToLower(leaf.Subject.CommonName)] = certs[i]
+		}
+		for _, name := range leaf.DNSNames {
+			store.nameToCert[strings.ToLower(name)] = certs[i]
+		}
+	}
+	return store
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestCert(t *testing.T, certFile, keyFile string, serial int64, names ...string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: `EC PRIVATE KEY`, Bytes: keyDER}), 0600))
+}
+
+func serialOf(cert *tls.Certificate) int64 {
+	if cert == nil {
+		return 0
+	}
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestCertManager(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, `cert.pem`), filepath.Join(dir, `key.pem`)
+	otherCert, otherKey := filepath.Join(dir, `other.pem`), filepath.Join(dir, `other.key`)
+	writeTestCert(t, certFile, keyFile, 1, `webx.top`)
+	writeTestCert(t, otherCert, otherKey, 2, `*.coscms.com`)
+
+	m := NewCertManager()
+	m.Delay = 10 * time.Millisecond
+	assert.NoError(t, m.Add(certFile, keyFile))
+	assert.NoError(t, m.Add(otherCert, otherKey))
+	assert.Error(t, m.Add(filepath.Join(dir, `none.pem`), keyFile))
+	assert.Equal(t, int64(1), serialOf(m.Lookup(`WEBX.top.`)))
+	assert.Equal(t, int64(2), serialOf(m.Lookup(`www.coscms.com`)))
+	assert.Nil(t, m.Lookup(`coscms.com`))
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: `unknown`})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), serialOf(cert))
+
+	// a broken certificate keeps serving the old one
+	assert.NoError(t, os.WriteFile(certFile, []byte(`broken`), 0600))
+	assert.Error(t, m.Reload())
+	assert.Equal(t, int64(1), serialOf(m.Lookup(`webx.top`)))
+
+	var reloaded []CertPair
+	m.OnReload = func(pair CertPair, err error) {
+		if err == nil {
+			reloaded = append(reloaded, pair)
+		}
+	}
+	assert.NoError(t, m.Watch())
+	defer m.Close()
+	writeTestCert(t, certFile, keyFile, 3, `webx.top`)
+	assert.Eventually(t, func() bool {
+		return serialOf(m.Lookup(`webx.top`)) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), serialOf(m.Lookup(`www.coscms.com`)))
+	assert.Len(t, m.Certificates(), 2)
+	assert.Equal(t, []CertPair{{CertFile: certFile, KeyFile: keyFile}}, reloaded)
+
+	assert.True(t, m.isWatched(filepath.Join(dir, `.`, `cert.pem`)))
+	assert.True(t, m.isWatched(filepath.Join(dir, `..data`)))
+	assert.False(t, m.isWatched(filepath.Join(dir, `cert.pem.swp`)))
+	assert.False(t, m.isWatched(filepath.Join(dir, `..data_tmp`)))
+}
+
+func TestCertManagerKubernetesSecret(t *testing.T) {
+	dir := t.TempDir()
+	writeVersion := func(version string, serial int64) {
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, version), 0700))
+		writeTestCert(t, filepath.Join(dir, version, `tls.crt`), filepath.Join(dir, version, `tls.key`), serial, `webx.top`)
+		assert.NoError(t, os.Symlink(version, filepath.Join(dir, `..data_tmp`)))
+		assert.NoError(t, os.Rename(filepath.Join(dir, `..data_tmp`), filepath.Join(dir, `..data`)))
+	}
+	writeVersion(`..v1`, 1)
+	certFile, keyFile := filepath.Join(dir, `tls.crt`), filepath.Join(dir, `tls.key`)
+	assert.NoError(t, os.Symlink(filepath.Join(`..data`, `tls.crt`), certFile))
+	assert.NoError(t, os.Symlink(filepath.Join(`..data`, `tls.key`), keyFile))
+
+	m := NewCertManager()
+	m.Delay = 10 * time.Millisecond
+	assert.NoError(t, m.Add(certFile, keyFile))
+	assert.NoError(t, m.Watch())
+	defer m.Close()
+	writeVersion(`..v2`, 2)
+	assert.Eventually(t, func() bool {
+		return serialOf(m.Lookup(`webx.top`)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConnectionStater is implemented by the requests of the engines
+// that expose the state of the TLS connection.
+type TLSConnectionStater interface {
+	// TLSConnectionState returns nil if the connection is not TLS.
+	TLSConnectionState() *tls.ConnectionState
+}
+
+// TLSConnectionState returns the TLS state of the connection of the request, or nil.
+func TLSConnectionState(req Request) *tls.ConnectionState {
+	if r, ok := req.(TLSConnectionStater); ok {
+		return r.TLSConnectionState()
+	}
+	return nil
+}
+
+// ParseClientAuthType parses the names used in configuration files:
+// `none`, `request`, `require`, `verify-if-given` and `require-and-verify`.
+func ParseClientAuthType(name string) (tls.ClientAuthType, error) {
+	switch name {
+	case ``, `none`:
+		return tls.NoClientCert, nil
+	case `request`:
+		return tls.RequestClientCert, nil
+	case `require`:
+		return tls.RequireAnyClientCert, nil
+	case `verify-if-given`:
+		return tls.VerifyClientCertIfGiven, nil
+	case `require-and-verify`:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf(`unsupported tls client auth type: %s`, name)
+	}
+}
+
+// NewCertPool creates a certificate pool from PEM files.
+func NewCertPool(pemFiles ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range pemFiles {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf(`no certificates found in %s`, file)
+		}
+	}
+	return pool, nil
+}
+
+// ErrClientAuthNotConfigured is returned by `InitClientAuth` when client CA files
+// are set without a `TLSClientAuth` policy that uses them.
+var ErrClientAuthNotConfigured = errors.New(`tls: TLSClientCAFiles is set but TLSClientAuth is none`)
+
+// InitClientAuth applies `TLSClientAuth` and `TLSClientCAFiles` to `TLSConfig`.
+// The configured policy is kept as is: setting CA files without a policy is an error
+// rather than an implicit `require-and-verify`.
+func (c *Config) InitClientAuth() error {
+	if c.TLSConfig == nil || (c.TLSClientAuth == tls.NoClientCert && len(c.TLSClientCAFiles) == 0) {
+		return nil
+	}
+	if c.TLSClientAuth == tls.NoClientCert {
+		return ErrClientAuthNotConfigured
+	}
+	if len(c.TLSClientCAFiles) > 0 {
+		pool, err := NewCertPool(c.TLSClientCAFiles...)
+		if err != nil {
+			return err
+		}
+		c.TLSConfig.ClientCAs = pool
+	}
+	c.TLSConfig.ClientAuth = c.TLSClientAuth
+	return nil
+}
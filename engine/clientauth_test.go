@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	caFile, keyFile := filepath.Join(dir, `ca.pem`), filepath.Join(dir, `ca.key`)
+	writeTestCert(t, caFile, keyFile, 1, `ca.webx.top`)
+
+	c := &Config{TLSConfig: &tls.Config{}, TLSClientCAFiles: []string{caFile}}
+	assert.Equal(t, ErrClientAuthNotConfigured, c.InitClientAuth())
+	assert.Equal(t, tls.NoClientCert, c.TLSConfig.ClientAuth)
+
+	c.TLSClientAuth = tls.VerifyClientCertIfGiven
+	assert.NoError(t, c.InitClientAuth())
+	assert.Equal(t, tls.VerifyClientCertIfGiven, c.TLSConfig.ClientAuth)
+	assert.NotNil(t, c.TLSConfig.ClientCAs)
+
+	c = &Config{TLSConfig: &tls.Config{}, TLSClientAuth: tls.RequestClientCert}
+	assert.NoError(t, c.InitClientAuth())
+	assert.Equal(t, tls.RequestClientCert, c.TLSConfig.ClientAuth)
+	assert.Nil(t, c.TLSConfig.ClientCAs)
+}
@@ -25,14 +25,31 @@ type Config struct {
 	TLSEmail           string
 	TLSCacheDir        string
 	TLSConfig          *tls.Config
-	TLSCertFile        string        // TLS certificate file path.
-	TLSKeyFile         string        // TLS key file path.
-	DisableHTTP2       bool          // Disables HTTP/2.
-	ReadTimeout        time.Duration // Maximum duration before timing out read of the request.
-	WriteTimeout       time.Duration // Maximum duration before timing out write of the response.
-	MaxConnsPerIP      int
+	TLSCertFile        string             // TLS certificate file path.
+	TLSKeyFile         string             // TLS key file path.
+	TLSAutoReload      bool               // Reloads the certificates when the files change.
+	TLSClientAuth      tls.ClientAuthType // Policy for TLS client authentication.
+	TLSClientCAFiles   []string           // CA files to verify the client certificates. Requires a TLSClientAuth other than NoClientCert.
+	DisableHTTP2       bool               // Disables HTTP/2.
+	H2C                bool               // Enables HTTP/2 over cleartext TCP (standard engine only).
+	ReadTimeout        time.Duration      // Maximum duration before timing out read of the request.
+	WriteTimeout       time.Duration      // Maximum duration before timing out write of the response.
+	MaxConnsPerIP      int                // Limited by the listener created by the config (see `LimitConnsPerIP`), not a custom Listener.
 	MaxRequestsPerConn int
 	MaxRequestBodySize int
+
+	UnixSocketMode  os.FileMode // File mode of the unix domain socket, e.g. 0660.
+	UnixSocketOwner string      // Owner of the unix domain socket: "user:group", "user" or ":group".
+
+	ProxyProtocol        bool          // Reads the PROXY protocol header sent by a TCP load balancer.
+	ProxyProtocolTrusted []string      // Trusted source CIDRs of the PROXY protocol header. Empty trusts all.
+	ProxyProtocolTimeout time.Duration // Timeout for reading the PROXY protocol header.
+
+	certManager *CertManager
+	rawListener net.Listener
+	connLimit   bool
+	onReject    func(net.Conn)
+	multi       bool // started by `Multi`, which notifies the readiness itself
 }
 
 //usage:
@@ -49,13 +66,10 @@ func (c *Config) InitTLSConfig(certAndKey ...string) *Config {
 		c.TLSCertFile = certAndKey[0]
 	}
 	c.TLSConfig = new(tls.Config)
-	if len(c.TLSCertFile) > 0 && len(c.TLSKeyFile) > 0 {
-		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
-		if err != nil {
-			panic(err)
-		}
-		c.TLSConfig.Certificates = append(c.TLSConfig.Certificates, cert)
+	if c.TLSAutoReload {
+		c.TLSConfig.GetCertificate = c.CertManager().GetCertificate
 	}
+	c.addTLSCert(c.TLSCertFile, c.TLSKeyFile)
 	if !c.DisableHTTP2 {
 		c.TLSConfig.NextProtos = append(c.TLSConfig.NextProtos, "h2")
 	}
@@ -66,16 +80,48 @@ func (c *Config) AddTLSCert(certFile, keyFile string) *Config {
 	if c.TLSConfig == nil {
 		c.InitTLSConfig()
 	}
-	if len(certFile) > 0 && len(keyFile) > 0 {
-		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-		if err != nil {
+	c.addTLSCert(certFile, keyFile)
+	return c
+}
+
+func (c *Config) addTLSCert(certFile, keyFile string) {
+	if len(certFile) == 0 || len(keyFile) == 0 {
+		return
+	}
+	if c.TLSAutoReload {
+		if err := c.CertManager().Add(certFile, keyFile); err != nil {
 			panic(err)
 		}
-		c.TLSConfig.Certificates = append(c.TLSConfig.Certificates, cert)
+		return
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		panic(err)
+	}
+	c.TLSConfig.Certificates = append(c.TLSConfig.Certificates, cert)
+}
+
+// CertManager returns the manager of the reloadable certificates (see `TLSAutoReload`).
+func (c *Config) CertManager() *CertManager {
+	if c.certManager == nil {
+		c.certManager = NewCertManager()
 	}
+	return c.certManager
+}
+
+func (c *Config) SetCertManager(m *CertManager) *Config {
+	c.certManager = m
 	return c
 }
 
+// CloseCertManager stops watching the certificate files.
+func (c *Config) CloseCertManager() error {
+	if c.certManager == nil {
+		return nil
+	}
+	return c.certManager.Close()
+}
+
 func (c *Config) NewAutoTLSManager(hosts ...string) *autocert.Manager {
 	autoTLSManager := &autocert.Manager{
 		Prompt: autocert.AcceptTOS,
@@ -121,6 +167,11 @@ func (c *Config) SupportAutoTLS(autoTLSManager *autocert.Manager, hosts ...strin
 	//c.TLSConfig.GetCertificate = autoTLSManager.GetCertificate
 	c.TLSConfig.BuildNameToCertificate()
 	c.TLSConfig.GetCertificate = func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if c.certManager != nil {
+			if cert := c.certManager.Lookup(clientHello.ServerName); cert != nil {
+				return cert, nil
+			}
+		}
 		if cert, ok := c.TLSConfig.NameToCertificate[clientHello.ServerName]; ok {
 			// Use provided certificate
 			return cert, nil
@@ -128,6 +179,9 @@ func (c *Config) SupportAutoTLS(autoTLSManager *autocert.Manager, hosts ...strin
 		if c.TLSAuto {
 			return autoTLSManager.GetCertificate(clientHello)
 		}
+		if c.certManager != nil {
+			return c.certManager.GetCertificate(clientHello)
+		}
 		return nil, nil // No certificate
 	}
 	return c
@@ -145,7 +199,15 @@ func (c *Config) InitTLSListener(before ...func() error) error {
 			return err
 		}
 	}
-	ln, err := NewListener(c.Address, c.ReusePort)
+	if err := c.InitClientAuth(); err != nil {
+		return err
+	}
+	if c.certManager != nil {
+		if err := c.certManager.Watch(); err != nil {
+			return err
+		}
+	}
+	ln, err := c.newListener()
 	if err != nil {
 		return err
 	}
@@ -162,7 +224,7 @@ func (c *Config) InitListener(before ...func() error) error {
 			return err
 		}
 	}
-	ln, err := NewListener(c.Address, c.ReusePort)
+	ln, err := c.newListener()
 	if err != nil {
 		return err
 	}
@@ -170,6 +232,53 @@ func (c *Config) InitListener(before ...func() error) error {
 	return nil
 }
 
+func (c *Config) newListener() (net.Listener, error) {
+	var ln net.Listener
+	err := withUmask(c.unixSocketUmask(), func() (err error) {
+		ln, err = NewListener(c.Address, c.ReusePort)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.rawListener = ln
+	if err := c.setupUnixSocket(ln); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	if c.ProxyProtocol {
+		pln, err := NewProxyProtocolListener(ln, c.ProxyProtocolTimeout, c.ProxyProtocolTrusted...)
+		if err != nil {
+			ln.Close()
+			return nil, err
+		}
+		ln = pln
+	}
+	if c.connLimit && c.MaxConnsPerIP > 0 {
+		// before the TLS listener, which must accept the *tls.Conn for HTTP/2 and `r.TLS`
+		ln = NewConnLimitListener(ln, c.MaxConnsPerIP, c.onReject)
+	}
+	return ln, nil
+}
+
+// LimitConnsPerIP makes the listener created by the config close the connections
+// exceeding `MaxConnsPerIP` before the TLS handshake. onReject is called before
+// closing a rejected connection. A custom Listener should be wrapped by
+// `NewConnLimitListener` before TLS instead.
+func (c *Config) LimitConnsPerIP(onReject func(net.Conn)) *Config {
+	c.connLimit = true
+	c.onReject = onReject
+	return c
+}
+
+// NotifyReady calls `NotifyReady` once the listener of the engine is bound, unless the
+// engine is started by `Multi` which notifies once all the listeners are bound.
+func (c *Config) NotifyReady() {
+	if !c.multi {
+		NotifyReady()
+	}
+}
+
 func (c *Config) Print(engine string) {
 	var s string
 	if c.TLSConfig != nil {
@@ -180,5 +289,14 @@ func (c *Config) Print(engine string) {
 
 func (c *Config) SetListener(ln net.Listener) *Config {
 	c.Listener = ln
+	c.rawListener = nil
 	return c
 }
+
+// RawListener returns the network listener before wrapping it with TLS or PROXY protocol.
+func (c *Config) RawListener() net.Listener {
+	if c.rawListener != nil {
+		return c.rawListener
+	}
+	return c.Listener
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"crypto/tls"
 	"net"
+	"os"
 	"time"
 )
 
@@ -72,6 +73,26 @@ func TLSKeyFile(v string) ConfigSetter {
 	}
 }
 
+// TLSAutoReload Reloads the certificates when the files change.
+func TLSAutoReload(v bool) ConfigSetter {
+	return func(c *Config) {
+		c.TLSAutoReload = v
+	}
+}
+
+func TLSClientAuth(v tls.ClientAuthType) ConfigSetter {
+	return func(c *Config) {
+		c.TLSClientAuth = v
+	}
+}
+
+// TLSClientCAFiles CA certificate files to verify the client certificates.
+func TLSClientCAFiles(v ...string) ConfigSetter {
+	return func(c *Config) {
+		c.TLSClientCAFiles = v
+	}
+}
+
 // DisableHTTP2 Disables HTTP/2.
 func DisableHTTP2(v bool) ConfigSetter {
 	return func(c *Config) {
@@ -79,6 +100,13 @@ func DisableHTTP2(v bool) ConfigSetter {
 	}
 }
 
+// H2C Enables HTTP/2 over cleartext TCP (standard engine only).
+func H2C(v bool) ConfigSetter {
+	return func(c *Config) {
+		c.H2C = v
+	}
+}
+
 // ReadTimeout Maximum duration before timing out read of the request.
 func ReadTimeout(v time.Duration) ConfigSetter {
 	return func(c *Config) {
@@ -110,3 +138,20 @@ func MaxRequestBodySize(v int) ConfigSetter {
 		c.MaxRequestBodySize = v
 	}
 }
+
+// ProxyProtocol Reads the PROXY protocol header sent by a TCP load balancer from the trusted sources.
+func ProxyProtocol(timeout time.Duration, trustedCIDRs ...string) ConfigSetter {
+	return func(c *Config) {
+		c.ProxyProtocol = true
+		c.ProxyProtocolTimeout = timeout
+		c.ProxyProtocolTrusted = trustedCIDRs
+	}
+}
+
+// UnixSocket File mode and owner ("user:group") of the unix domain socket.
+func UnixSocket(mode os.FileMode, owner string) ConfigSetter {
+	return func(c *Config) {
+		c.UnixSocketMode = mode
+		c.UnixSocketOwner = owner
+	}
+}
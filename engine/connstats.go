@@ -0,0 +1,236 @@
+package engine
+
+import (
+	"net"
+	"sync"
+)
+
+// ConnState represents the state of a client connection. Its values are the same
+// as the ones of `http.ConnState` and `fasthttp.ConnState`.
+type ConnState int
+
+const (
+	StateNew ConnState = iota
+	StateActive
+	StateIdle
+	StateHijacked
+	StateClosed
+)
+
+// ConnStats is the snapshot of the client connections of a server.
+type ConnStats struct {
+	Open     int            `json:"open"`     // connections not closed or hijacked
+	New      int            `json:"new"`      // connections without any request yet
+	Active   int            `json:"active"`   // connections serving requests
+	Idle     int            `json:"idle"`     // keep-alive connections waiting for requests
+	Hijacked uint64         `json:"hijacked"` // hijacked connections (e.g. websocket) since start
+	Accepted uint64         `json:"accepted"` // accepted connections since start
+	Rejected uint64         `json:"rejected"` // connections rejected by `MaxConnsPerIP` since start
+	PerIP    map[string]int `json:"perIP"`    // open connections per client IP
+}
+
+// Add adds the numbers of other to s.
+func (s *ConnStats) Add(other ConnStats) {
+	s.Open += other.Open
+	s.New += other.New
+	s.Active += other.Active
+	s.Idle += other.Idle
+	s.Hijacked += other.Hijacked
+	s.Accepted += other.Accepted
+	s.Rejected += other.Rejected
+	if s.PerIP == nil {
+		s.PerIP = map[string]int{}
+	}
+	for ip, n := range other.PerIP {
+		s.PerIP[ip] += n
+	}
+}
+
+// StatsProvider is implemented by the engines which track their client connections.
+type StatsProvider interface {
+	// Stats returns the statistics of the client connections.
+	Stats() ConnStats
+}
+
+// EngineStats returns the statistics of the client connections of e, and false
+// if e does not track them.
+func EngineStats(e Engine) (ConnStats, bool) {
+	if p, ok := e.(StatsProvider); ok {
+		return p.Stats(), true
+	}
+	return ConnStats{}, false
+}
+
+type trackedConn struct {
+	state    ConnState
+	requests int
+}
+
+// ConnTracker tracks the states of the client connections through the `ConnState`
+// hooks of the servers. The connections are keyed on the conn passed to the hooks;
+// their IP addresses are only resolved by `Stats`, outside the hooks and the lock.
+type ConnTracker struct {
+	mutex    sync.Mutex
+	conns    map[net.Conn]*trackedConn
+	hijacked uint64
+	accepted uint64
+	rejected uint64
+}
+
+func NewConnTracker() *ConnTracker {
+	return &ConnTracker{
+		conns: map[net.Conn]*trackedConn{},
+	}
+}
+
+// SetState records the state of the connection.
+func (t *ConnTracker) SetState(conn net.Conn, state ConnState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	c, ok := t.conns[conn]
+	if state == StateNew {
+		if !ok {
+			t.accepted++
+			t.conns[conn] = &trackedConn{state: state}
+		}
+		return
+	}
+	if !ok {
+		return
+	}
+	switch state {
+	case StateHijacked, StateClosed:
+		if state == StateHijacked {
+			t.hijacked++
+		}
+		delete(t.conns, conn)
+	default:
+		c.state = state
+	}
+}
+
+// Reject counts a connection rejected before being served (e.g. by `ConnLimitListener`).
+func (t *ConnTracker) Reject(net.Conn) {
+	t.mutex.Lock()
+	t.rejected++
+	t.mutex.Unlock()
+}
+
+// AddRequest counts a request on the connection and returns the number of requests served on it.
+func (t *ConnTracker) AddRequest(conn net.Conn) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	c, ok := t.conns[conn]
+	if !ok {
+		return 1
+	}
+	c.requests++
+	return c.requests
+}
+
+func (t *ConnTracker) Stats() ConnStats {
+	t.mutex.Lock()
+	stats := ConnStats{
+		Open:     len(t.conns),
+		Hijacked: t.hijacked,
+		Accepted: t.accepted,
+		Rejected: t.rejected,
+		PerIP:    map[string]int{},
+	}
+	conns := make([]net.Conn, 0, len(t.conns))
+	for conn, c := range t.conns {
+		conns = append(conns, conn)
+		switch c.state {
+		case StateNew:
+			stats.New++
+		case StateActive:
+			stats.Active++
+		case StateIdle:
+			stats.Idle++
+		}
+	}
+	t.mutex.Unlock()
+	for _, conn := range conns {
+		stats.PerIP[connIP(conn)]++
+	}
+	return stats
+}
+
+// ConnLimitListener wraps a listener to close the connections exceeding
+// `MaxConnsPerIP` as soon as they are accepted, before they are served.
+// A connection is counted until it is closed.
+type ConnLimitListener struct {
+	net.Listener
+	MaxConnsPerIP int
+	OnReject      func(net.Conn) // Called before closing a rejected connection.
+
+	mutex sync.Mutex
+	perIP map[string]int
+}
+
+func NewConnLimitListener(ln net.Listener, maxConnsPerIP int, onReject func(net.Conn)) *ConnLimitListener {
+	return &ConnLimitListener{
+		Listener:      ln,
+		MaxConnsPerIP: maxConnsPerIP,
+		OnReject:      onReject,
+		perIP:         map[string]int{},
+	}
+}
+
+func (l *ConnLimitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		ip := connIP(conn)
+		l.mutex.Lock()
+		if l.MaxConnsPerIP > 0 && l.perIP[ip] >= l.MaxConnsPerIP {
+			l.mutex.Unlock()
+			if l.OnReject != nil {
+				l.OnReject(conn)
+			}
+			conn.Close()
+			continue
+		}
+		l.perIP[ip]++
+		l.mutex.Unlock()
+		return &limitedConn{Conn: conn, release: func() { l.release(ip) }}, nil
+	}
+}
+
+func (l *ConnLimitListener) release(ip string) {
+	l.mutex.Lock()
+	if l.perIP[ip] <= 1 {
+		delete(l.perIP, ip)
+	} else {
+		l.perIP[ip]--
+	}
+	l.mutex.Unlock()
+}
+
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+func connIP(conn net.Conn) string {
+	addr := conn.RemoteAddr()
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP.String()
+	case *net.UnixAddr:
+		return UnixRemoteAddress
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -6,6 +6,7 @@ package fasthttp
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"io"
 	"mime/multipart"
@@ -99,6 +100,9 @@ func (r *Request) Proto() string {
 }
 
 func (r *Request) RemoteAddress() string {
+	if addr, ok := engine.UnixPeerAddress(r.context.LocalAddr(), r.context.RemoteAddr()); ok {
+		return addr
+	}
 	return r.context.RemoteAddr().String()
 }
 
@@ -266,3 +270,7 @@ func parseBasicAuth(auth string) (username, password string, ok bool) {
 	}
 	return cs[:s], cs[s+1:], true
 }
+
+func (r *Request) TLSConnectionState() *tls.ConnectionState {
+	return r.context.TLSConnectionState()
+}
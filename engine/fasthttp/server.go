@@ -5,6 +5,7 @@ package fasthttp
 
 import (
 	"context"
+	"net"
 	"sync"
 
 	"github.com/admpub/fasthttp"
@@ -20,6 +21,7 @@ type (
 		handler engine.Handler
 		logger  logger.Logger
 		pool    *pool
+		conns   *engine.ConnTracker
 	}
 
 	pool struct {
@@ -61,6 +63,7 @@ func NewWithConfig(c *engine.Config) (s *Server) {
 			MaxRequestBodySize: c.MaxRequestBodySize,
 		},
 		config: c,
+		conns:  engine.NewConnTracker(), // MaxConnsPerIP is limited by fasthttp
 		pool: &pool{
 			request: sync.Pool{
 				New: func() interface{} {
@@ -94,9 +97,17 @@ func NewWithConfig(c *engine.Config) (s *Server) {
 		logger: log.GetLogger("echo"),
 	}
 	s.Handler = s.ServeHTTP
+	s.Server.ConnState = func(conn net.Conn, state fasthttp.ConnState) {
+		s.conns.SetState(conn, engine.ConnState(state))
+	}
 	return
 }
 
+// Stats implements `engine.StatsProvider#Stats` function.
+func (s *Server) Stats() engine.ConnStats {
+	return s.conns.Stats()
+}
+
 func (s *Server) SetHandler(h engine.Handler) {
 	s.handler = h
 }
@@ -105,21 +116,27 @@ func (s *Server) SetLogger(l logger.Logger) {
 	s.logger = l
 }
 
-// Start implements `engine.Server#Start` function.
-func (s *Server) Start() error {
-	if s.config.Listener == nil {
-		s.config.DisableHTTP2 = true
-		err := s.config.InitListener(func() error {
-			if s.config.TLSConfig == nil {
-				return nil
-			}
-			s.config.TLSConfig.PreferServerCipherSuites = true
+// InitListener implements `engine.ListenerInitializer#InitListener` function.
+func (s *Server) InitListener() error {
+	if s.config.Listener != nil {
+		return nil
+	}
+	s.config.DisableHTTP2 = true
+	return s.config.InitListener(func() error {
+		if s.config.TLSConfig == nil {
 			return nil
-		})
-		if err != nil {
-			return err
 		}
+		s.config.TLSConfig.PreferServerCipherSuites = true
+		return nil
+	})
+}
+
+// Start implements `engine.Server#Start` function.
+func (s *Server) Start() error {
+	if err := s.InitListener(); err != nil {
+		return err
 	}
+	s.config.NotifyReady()
 	s.config.Print(`fast`)
 	return s.Serve(s.config.Listener)
 
@@ -127,6 +144,7 @@ func (s *Server) Start() error {
 
 // Stop implements `engine.Server#Stop` function.
 func (s *Server) Stop() error {
+	s.config.CloseCertManager()
 	if s.config.Listener == nil {
 		return nil
 	}
@@ -134,6 +152,7 @@ func (s *Server) Stop() error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.config.CloseCertManager()
 	return s.Server.Shutdown()
 }
 
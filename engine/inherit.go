@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// EnvListenFDs is the number of listeners passed to the child process by `StartProcess`.
+	EnvListenFDs = `ECHO_LISTEN_FDS`
+	// EnvReadyFD is the file descriptor which the child process closes when it is serving.
+	EnvReadyFD = `ECHO_READY_FD`
+
+	listenFDsStart = 3 // the first file descriptor after stdin, stdout and stderr
+)
+
+var inherited = struct {
+	once      sync.Once
+	mutex     sync.Mutex
+	listeners []net.Listener
+}{}
+
+// InheritedListeners returns the listeners which have not been taken yet from
+// the ones passed by the parent process (graceful restart) or by systemd (socket activation).
+func InheritedListeners() []net.Listener {
+	inherited.once.Do(loadInheritedListeners)
+	inherited.mutex.Lock()
+	defer inherited.mutex.Unlock()
+	return append([]net.Listener(nil), inherited.listeners...)
+}
+
+func loadInheritedListeners() {
+	var n int
+	var names []string
+	if pid, _ := strconv.Atoi(os.Getenv(`LISTEN_PID`)); pid == os.Getpid() {
+		n, _ = strconv.Atoi(os.Getenv(`LISTEN_FDS`))
+		if fdNames := os.Getenv(`LISTEN_FDNAMES`); len(fdNames) > 0 {
+			names = strings.Split(fdNames, `:`)
+		}
+		os.Unsetenv(`LISTEN_PID`)
+		os.Unsetenv(`LISTEN_FDS`)
+		os.Unsetenv(`LISTEN_FDNAMES`)
+	} else {
+		n, _ = strconv.Atoi(os.Getenv(EnvListenFDs))
+		os.Unsetenv(EnvListenFDs)
+	}
+	for i := 0; i < n; i++ {
+		name := `listener` + strconv.Itoa(i)
+		if i < len(names) {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			continue
+		}
+		inherited.listeners = append(inherited.listeners, ln)
+	}
+}
+
+// TakeInheritedListener removes the inherited listener on the address from the
+// inherited ones and returns it, or nil if there is none.
+func TakeInheritedListener(network, address string) net.Listener {
+	inherited.once.Do(loadInheritedListeners)
+	inherited.mutex.Lock()
+	defer inherited.mutex.Unlock()
+	for i, ln := range inherited.listeners {
+		if !sameAddr(ln.Addr(), network, address) {
+			continue
+		}
+		inherited.listeners = append(inherited.listeners[:i], inherited.listeners[i+1:]...)
+		if uln, ok := ln.(*net.UnixListener); ok {
+			// the socket file of an inherited listener is not removed on close by default
+			uln.SetUnlinkOnClose(true)
+		}
+		return ln
+	}
+	return nil
+}
+
+func sameAddr(addr net.Addr, network, address string) bool {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if !strings.HasPrefix(network, `tcp`) {
+			return false
+		}
+		b, err := net.ResolveTCPAddr(network, address)
+		if err != nil || a.Port != b.Port {
+			return false
+		}
+		if len(b.IP) == 0 || b.IP.IsUnspecified() {
+			return len(a.IP) == 0 || a.IP.IsUnspecified()
+		}
+		return a.IP.Equal(b.IP)
+	default:
+		return addr.Network() == network && addr.String() == address
+	}
+}
+
+// NotifyReady tells the parent process started `StartProcess` that this process is serving.
+func NotifyReady() {
+	fd, err := strconv.Atoi(os.Getenv(EnvReadyFD))
+	if err != nil {
+		return
+	}
+	os.Unsetenv(EnvReadyFD)
+	os.NewFile(uintptr(fd), `ready`).Close()
+}
@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeInheritedListener(t *testing.T) {
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	assert.NoError(t, err)
+	defer ln.Close()
+	inherited.once.Do(loadInheritedListeners)
+	inherited.listeners = append(inherited.listeners, ln)
+
+	addr := ln.Addr().String()
+	_, port, _ := net.SplitHostPort(addr)
+	assert.Nil(t, TakeInheritedListener(`tcp`, `127.0.0.2:`+port))
+	assert.Nil(t, TakeInheritedListener(`unix`, addr))
+	assert.Len(t, InheritedListeners(), 1)
+
+	got, err := NewListener(`tcp://`+addr, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ln, got)
+	assert.Len(t, InheritedListeners(), 0)
+
+	assert.True(t, sameAddr(&net.TCPAddr{IP: net.IPv6unspecified, Port: 80}, `tcp`, `:80`))
+	assert.True(t, sameAddr(&net.TCPAddr{Port: 80}, `tcp4`, `0.0.0.0:80`))
+	assert.False(t, sameAddr(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}, `tcp`, `:80`))
+}
@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// NewListener returns the listener inherited from the parent process or systemd
+// on the address if there is one, otherwise listens on it.
+// For `unix://` addresses the stale socket file is removed before listening.
 func NewListener(address string, reuse bool) (net.Listener, error) {
 	scheme := "tcp"
 	delim := "://"
@@ -12,5 +15,14 @@ func NewListener(address string, reuse bool) (net.Listener, error) {
 		scheme = address[0:pos]
 		address = address[pos+len(delim):]
 	}
+	if ln := TakeInheritedListener(scheme, address); ln != nil {
+		return ln, nil
+	}
+	if IsUnixScheme(scheme) {
+		if err := RemoveStaleUnixSocket(address); err != nil {
+			return nil, err
+		}
+		return newListener(scheme, address, false)
+	}
 	return newListener(scheme, address, reuse)
 }
@@ -0,0 +1,170 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/admpub/log"
+
+	"github.com/webx-top/echo/logger"
+)
+
+var (
+	_ Engine        = &Multi{}
+	_ StatsProvider = &Multi{}
+)
+
+// Multi is an engine composed of several engines (e.g. HTTP, HTTPS, Unix socket and
+// admin ports) serving the same handler. They are started together and stopped together.
+type Multi struct {
+	engines  []Engine
+	handlers map[int]Handler // the engines with their own handler
+	logger   logger.Logger
+	stopping atomic.Bool
+}
+
+// NewMulti returns an engine which serves on all the engines.
+//
+//	e.Run(engine.NewMulti(standard.New(`:80`), standard.NewWithTLS(`:443`, certFile, keyFile), standard.New(`unix:///run/app.sock`)))
+func NewMulti(engines ...Engine) *Multi {
+	return &Multi{
+		engines:  engines,
+		handlers: map[int]Handler{},
+		logger:   log.GetLogger(`echo`),
+	}
+}
+
+// Add adds engines which serve the handler set by `SetHandler()`.
+func (m *Multi) Add(engines ...Engine) *Multi {
+	m.engines = append(m.engines, engines...)
+	return m
+}
+
+// AddWithHandler adds an engine which keeps serving h after `SetHandler()`.
+func (m *Multi) AddWithHandler(h Handler, e Engine) *Multi {
+	m.handlers[len(m.engines)] = h
+	e.SetHandler(h)
+	m.engines = append(m.engines, e)
+	return m
+}
+
+func (m *Multi) Engines() []Engine {
+	return m.engines
+}
+
+func (m *Multi) SetHandler(h Handler) {
+	for i, e := range m.engines {
+		if _, ok := m.handlers[i]; ok {
+			continue
+		}
+		e.SetHandler(h)
+	}
+}
+
+func (m *Multi) SetLogger(l logger.Logger) {
+	m.logger = l
+	for _, e := range m.engines {
+		e.SetLogger(l)
+	}
+}
+
+// ListenerInitializer is implemented by the engines which can bind their listener
+// before `Start`, so that `Multi` fails before serving on any of them.
+type ListenerInitializer interface {
+	InitListener() error
+}
+
+// Start binds the listeners of all the engines implementing `ListenerInitializer`,
+// notifies the readiness once (see `NotifyReady`), then starts all the engines
+// and blocks until all of them stop. When a listener
+// can not be bound, the already bound ones are closed and no engine is started.
+// When one of the engines fails, the others are stopped and its error is returned.
+func (m *Multi) Start() error {
+	if len(m.engines) == 0 {
+		return errors.New(`no engine to start`)
+	}
+	m.stopping.Store(false)
+	for _, e := range m.engines {
+		e.Config().multi = true
+		initializer, ok := e.(ListenerInitializer)
+		if !ok {
+			continue
+		}
+		if err := initializer.InitListener(); err != nil {
+			m.logger.Errorf(`engine on %s failed to listen: %v`, e.Config().Address, err)
+			m.Stop()
+			return err
+		}
+	}
+	NotifyReady()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, e := range m.engines {
+		wg.Add(1)
+		go func(e Engine) {
+			defer wg.Done()
+			err := e.Start()
+			if err == nil || errors.Is(err, http.ErrServerClosed) || m.stopping.Load() {
+				return
+			}
+			m.logger.Errorf(`engine on %s stopped: %v`, e.Config().Address, err)
+			once.Do(func() {
+				firstErr = err
+				m.Stop()
+			})
+		}(e)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// Stop stops all the engines.
+func (m *Multi) Stop() error {
+	m.stopping.Store(true)
+	errs := make([]error, 0, len(m.engines))
+	for _, e := range m.engines {
+		errs = append(errs, e.Stop())
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown shuts all the engines down gracefully in parallel.
+func (m *Multi) Shutdown(ctx context.Context) error {
+	m.stopping.Store(true)
+	errs := make([]error, len(m.engines))
+	var wg sync.WaitGroup
+	for i, e := range m.engines {
+		wg.Add(1)
+		go func(i int, e Engine) {
+			defer wg.Done()
+			errs[i] = e.Shutdown(ctx)
+		}(i, e)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Stats returns the sum of the statistics of the engines implementing `StatsProvider`.
+func (m *Multi) Stats() ConnStats {
+	stats := ConnStats{PerIP: map[string]int{}}
+	for _, e := range m.engines {
+		if other, ok := EngineStats(e); ok {
+			stats.Add(other)
+		}
+	}
+	return stats
+}
+
+// Config returns the config of the first engine.
+func (m *Multi) Config() *Config {
+	if len(m.engines) == 0 {
+		return &Config{}
+	}
+	return m.engines[0].Config()
+}
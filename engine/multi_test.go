@@ -0,0 +1,81 @@
+package engine_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo/engine"
+	"github.com/webx-top/echo/engine/standard"
+)
+
+func TestMulti(t *testing.T) {
+	var addrs []string
+	m := engine.NewMulti()
+	for i := 0; i < 2; i++ {
+		ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+		assert.NoError(t, err)
+		addrs = append(addrs, ln.Addr().String())
+		m.Add(standard.New(``, engine.Listener(ln)))
+	}
+	admin, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	assert.NoError(t, err)
+	m.AddWithHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		res.Write([]byte(`admin`))
+	}), standard.New(``, engine.Listener(admin)))
+	m.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		res.Write([]byte(`app`))
+	}))
+	done := make(chan error)
+	go func() {
+		done <- m.Start()
+	}()
+	get := func(addr string) string {
+		resp, err := http.Get(`http://` + addr + `/`)
+		if err != nil {
+			return err.Error()
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+	assert.Eventually(t, func() bool { return get(addrs[1]) == `app` }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, `app`, get(addrs[0]))
+	assert.Equal(t, `admin`, get(admin.Addr().String()))
+
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+
+	// the other engines are stopped when one of them fails
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	assert.NoError(t, err)
+	m = engine.NewMulti(standard.New(``, engine.Listener(ln)), standard.New(`127.0.0.1:-1`))
+	m.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {}))
+	go func() {
+		done <- m.Start()
+	}()
+	select {
+	case err = <-done:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal(`the engines are not stopped`)
+	}
+
+	_, err = ln.Accept()
+	assert.Error(t, err)
+
+	// no engine is started when a listener can not be bound
+	lazy := standard.New(`127.0.0.1:0`)
+	m = engine.NewMulti(lazy, standard.New(`127.0.0.1:-1`))
+	m.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {}))
+	assert.Error(t, m.Start())
+	if assert.NotNil(t, lazy.Config().Listener) {
+		_, err = lazy.Config().Listener.Accept()
+		assert.Error(t, err)
+	}
+}
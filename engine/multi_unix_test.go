@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package engine_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo/engine"
+	"github.com/webx-top/echo/engine/standard"
+)
+
+func TestMultiNotifyReady(t *testing.T) {
+	r, w, err := os.Pipe()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer r.Close()
+	// the engine closes its own copy of the write end
+	fd, err := syscall.Dup(int(w.Fd()))
+	w.Close()
+	if !assert.NoError(t, err) {
+		return
+	}
+	t.Setenv(engine.EnvReadyFD, strconv.Itoa(fd))
+	first := standard.New(`127.0.0.1:0`)
+	second := standard.New(`127.0.0.1:0`)
+	m := engine.NewMulti(first, second)
+	m.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {}))
+	done := make(chan error)
+	go func() {
+		done <- m.Start()
+	}()
+	// the ready pipe is closed once both listeners are bound
+	_, err = io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Empty(t, os.Getenv(engine.EnvReadyFD))
+	for _, e := range []*standard.Server{first, second} {
+		addr := e.Config().Listener.Addr().String()
+		conn, err := net.Dial(`tcp`, addr)
+		if assert.NoError(t, err) {
+			conn.Close()
+		}
+	}
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,335 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProxyProtocolTimeout is the default timeout for reading the PROXY protocol header.
+var DefaultProxyProtocolTimeout = 5 * time.Second
+
+var (
+	ErrProxyProtocolHeader = errors.New(`invalid PROXY protocol header`)
+
+	proxyProtocolV1Prefix    = []byte(`PROXY `)
+	proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// ProxyProtocolListener wraps a listener to read the PROXY protocol v1/v2 header
+// sent by a TCP load balancer, so that `RemoteAddr()` of the connections returns
+// the address of the real client.
+type ProxyProtocolListener struct {
+	net.Listener
+	Timeout time.Duration         // Timeout for reading the header.
+	OnError func(net.Conn, error) // Called concurrently before closing a connection with an invalid header.
+	trusted []*net.IPNet
+
+	once     sync.Once
+	accepted chan proxyProtocolAccept
+	done     chan struct{}
+	closed   sync.Once
+	mutex    sync.Mutex
+	pending  map[net.Conn]struct{} // the connections whose header is being read
+}
+
+type proxyProtocolAccept struct {
+	conn net.Conn
+	err  error
+}
+
+// NewProxyProtocolListener wraps ln. The header is only parsed (and required) on the
+// connections from trustedCIDRs; other connections are passed through unchanged.
+// An empty trustedCIDRs trusts all sources.
+func NewProxyProtocolListener(ln net.Listener, timeout time.Duration, trustedCIDRs ...string) (*ProxyProtocolListener, error) {
+	if timeout <= 0 {
+		timeout = DefaultProxyProtocolTimeout
+	}
+	l := &ProxyProtocolListener{
+		Listener: ln,
+		Timeout:  timeout,
+		accepted: make(chan proxyProtocolAccept),
+		done:     make(chan struct{}),
+		pending:  map[net.Conn]struct{}{},
+	}
+	for _, cidr := range trustedCIDRs {
+		if !strings.Contains(cidr, `/`) {
+			if strings.Contains(cidr, `:`) {
+				cidr += `/128`
+			} else {
+				cidr += `/32`
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		l.trusted = append(l.trusted, ipNet)
+	}
+	return l, nil
+}
+
+func (l *ProxyProtocolListener) isTrusted(addr net.Addr) bool {
+	if len(l.trusted) == 0 {
+		return true
+	}
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, ipNet := range l.trusted {
+		if ipNet.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Accept returns the next connection whose header has been read. The headers are
+// read in a goroutine per connection, within `Timeout`, so that a slow client can
+// not block the accept loop. The connections with an invalid header are closed
+// and never returned.
+func (l *ProxyProtocolListener) Accept() (net.Conn, error) {
+	l.once.Do(func() {
+		go l.acceptLoop()
+	})
+	select {
+	case r := <-l.accepted:
+		return r.conn, r.err
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes the listener and the connections still waiting for their header.
+func (l *ProxyProtocolListener) Close() error {
+	l.closed.Do(func() {
+		close(l.done)
+		l.mutex.Lock()
+		for conn := range l.pending {
+			conn.Close()
+		}
+		l.pending = nil
+		l.mutex.Unlock()
+	})
+	return l.Listener.Close()
+}
+
+// addPending tracks the connection until its header has been read. It returns
+// false if the listener is closed.
+func (l *ProxyProtocolListener) addPending(conn net.Conn) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.pending == nil {
+		return false
+	}
+	l.pending[conn] = struct{}{}
+	return true
+}
+
+func (l *ProxyProtocolListener) removePending(conn net.Conn) {
+	l.mutex.Lock()
+	delete(l.pending, conn)
+	l.mutex.Unlock()
+}
+
+func (l *ProxyProtocolListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			select {
+			case l.accepted <- proxyProtocolAccept{err: err}:
+			case <-l.done:
+				return
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		if !l.isTrusted(conn.RemoteAddr()) {
+			l.deliver(conn)
+			continue
+		}
+		if !l.addPending(conn) {
+			conn.Close()
+			return
+		}
+		go l.handshake(conn)
+	}
+}
+
+func (l *ProxyProtocolListener) handshake(conn net.Conn) {
+	c := &proxyProtocolConn{Conn: conn, reader: bufio.NewReader(conn)}
+	conn.SetReadDeadline(time.Now().Add(l.Timeout))
+	var err error
+	c.remoteAddr, c.localAddr, err = ReadProxyProtocolHeader(c.reader)
+	l.removePending(conn)
+	if err == nil {
+		err = conn.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		select {
+		case <-l.done: // closed by Close
+		default:
+			if l.OnError != nil {
+				l.OnError(conn, err)
+			}
+		}
+		conn.Close()
+		return
+	}
+	l.deliver(c)
+}
+
+func (l *ProxyProtocolListener) deliver(conn net.Conn) {
+	select {
+	case l.accepted <- proxyProtocolAccept{conn: conn}:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+// proxyProtocolConn is a connection whose header has already been read.
+type proxyProtocolConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	remoteAddr net.Addr
+	localAddr  net.Addr
+}
+
+func (c *proxyProtocolConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *proxyProtocolConn) LocalAddr() net.Addr {
+	if c.localAddr != nil {
+		return c.localAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+// ReadProxyProtocolHeader reads a PROXY protocol v1 or v2 header.
+// The returned addresses are nil for the "UNKNOWN" (v1) and "LOCAL" (v2) commands.
+func ReadProxyProtocolHeader(r *bufio.Reader) (src net.Addr, dst net.Addr, err error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, nil, fmt.Errorf(`%w: %v`, ErrProxyProtocolHeader, err)
+	}
+	switch b[0] {
+	case proxyProtocolV1Prefix[0]:
+		if b, err = r.Peek(len(proxyProtocolV1Prefix)); err == nil && bytes.Equal(b, proxyProtocolV1Prefix) {
+			return readProxyProtocolV1(r)
+		}
+	case proxyProtocolV2Signature[0]:
+		if b, err = r.Peek(len(proxyProtocolV2Signature)); err == nil && bytes.Equal(b, proxyProtocolV2Signature) {
+			return readProxyProtocolV2(r)
+		}
+	}
+	return nil, nil, ErrProxyProtocolHeader
+}
+
+func readProxyProtocolV1(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	// at most 107 bytes: "PROXY TCP6 <src> <dst> <sport> <dport>\r\n"
+	var line []byte
+	for len(line) < 107 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, fmt.Errorf(`%w: %v`, ErrProxyProtocolHeader, err)
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, ErrProxyProtocolHeader
+	}
+	fields := strings.Split(string(line[:len(line)-2]), ` `)
+	if len(fields) >= 2 && fields[1] == `UNKNOWN` {
+		return nil, nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != `TCP4` && fields[1] != `TCP6`) {
+		return nil, nil, ErrProxyProtocolHeader
+	}
+	src, err := parseProxyProtocolAddr(fields[2], fields[4])
+	if err != nil {
+		return nil, nil, err
+	}
+	dst, err := parseProxyProtocolAddr(fields[3], fields[5])
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+func parseProxyProtocolAddr(ip string, port string) (*net.TCPAddr, error) {
+	addr := &net.TCPAddr{IP: net.ParseIP(ip)}
+	if addr.IP == nil {
+		return nil, ErrProxyProtocolHeader
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, ErrProxyProtocolHeader
+	}
+	addr.Port = int(p)
+	return addr, nil
+}
+
+func readProxyProtocolV2(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, fmt.Errorf(`%w: %v`, ErrProxyProtocolHeader, err)
+	}
+	verCmd, family := header[12], header[13]
+	if verCmd>>4 != 2 {
+		return nil, nil, ErrProxyProtocolHeader
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, fmt.Errorf(`%w: %v`, ErrProxyProtocolHeader, err)
+	}
+	switch verCmd & 0x0F {
+	case 0x0: // LOCAL: connections of the balancer itself (e.g. health checks)
+		return nil, nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, nil, ErrProxyProtocolHeader
+	}
+	var ipLen int
+	switch family >> 4 {
+	case 0x1: // AF_INET
+		ipLen = net.IPv4len
+	case 0x2: // AF_INET6
+		ipLen = net.IPv6len
+	default: // AF_UNSPEC, AF_UNIX
+		return nil, nil, nil
+	}
+	if len(payload) < ipLen*2+4 {
+		return nil, nil, ErrProxyProtocolHeader
+	}
+	src := &net.TCPAddr{
+		IP:   net.IP(append([]byte(nil), payload[:ipLen]...)),
+		Port: int(binary.BigEndian.Uint16(payload[ipLen*2:])),
+	}
+	dst := &net.TCPAddr{
+		IP:   net.IP(append([]byte(nil), payload[ipLen:ipLen*2]...)),
+		Port: int(binary.BigEndian.Uint16(payload[ipLen*2+2:])),
+	}
+	return src, dst, nil
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadProxyProtocolHeader(t *testing.T) {
+	src, dst, err := ReadProxyProtocolHeader(bufio.NewReader(strings.NewReader("PROXY TCP4 192.168.0.1 10.0.0.1 56324 443\r\nGET /")))
+	assert.NoError(t, err)
+	assert.Equal(t, `192.168.0.1:56324`, src.String())
+	assert.Equal(t, `10.0.0.1:443`, dst.String())
+
+	src, _, err = ReadProxyProtocolHeader(bufio.NewReader(strings.NewReader("PROXY UNKNOWN\r\n")))
+	assert.NoError(t, err)
+	assert.Nil(t, src)
+
+	_, _, err = ReadProxyProtocolHeader(bufio.NewReader(strings.NewReader("GET / HTTP/1.1\r\n")))
+	assert.ErrorIs(t, err, ErrProxyProtocolHeader)
+	_, _, err = ReadProxyProtocolHeader(bufio.NewReader(strings.NewReader("PROXY TCP4 192.168.0.1 10.0.0.1 abc 443\r\n")))
+	assert.ErrorIs(t, err, ErrProxyProtocolHeader)
+
+	r := bufio.NewReader(bytes.NewReader(proxyProtocolV2Header(0x21, net.ParseIP(`2001:db8::1`), 8080)))
+	src, _, err = ReadProxyProtocolHeader(r)
+	assert.NoError(t, err)
+	assert.Equal(t, `[2001:db8::1]:8080`, src.String())
+	rest, _ := io.ReadAll(r)
+	assert.Equal(t, `GET /`, string(rest))
+
+	src, _, err = ReadProxyProtocolHeader(bufio.NewReader(bytes.NewReader(proxyProtocolV2Header(0x20, net.ParseIP(`2001:db8::1`), 8080))))
+	assert.NoError(t, err)
+	assert.Nil(t, src)
+}
+
+func proxyProtocolV2Header(verCmd byte, ip net.IP, port uint16) []byte {
+	payload := make([]byte, 36+3) // addresses and a TLV
+	copy(payload, ip.To16())
+	copy(payload[16:], net.IPv6loopback)
+	binary.BigEndian.PutUint16(payload[32:], port)
+	binary.BigEndian.PutUint16(payload[34:], 443)
+	b := append([]byte(nil), proxyProtocolV2Signature...)
+	b = append(b, verCmd, 0x21, 0, 0)
+	binary.BigEndian.PutUint16(b[14:], uint16(len(payload)))
+	b = append(b, payload...)
+	return append(b, `GET /`...)
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	_, err := NewProxyProtocolListener(nil, 0, `bad`)
+	assert.Error(t, err)
+
+	listen := func(trusted string) *ProxyProtocolListener {
+		ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+		assert.NoError(t, err)
+		pln, err := NewProxyProtocolListener(ln, 100*time.Millisecond, trusted)
+		assert.NoError(t, err)
+		return pln
+	}
+	dial := func(pln *ProxyProtocolListener, data string) net.Conn {
+		client, err := net.Dial(`tcp`, pln.Addr().String())
+		assert.NoError(t, err)
+		client.Write([]byte(data))
+		return client
+	}
+	accept := func(pln *ProxyProtocolListener) string {
+		conn, err := pln.Accept()
+		assert.NoError(t, err)
+		return conn.RemoteAddr().String() + `|` + readAll(conn)
+	}
+
+	pln := listen(`127.0.0.1`)
+	var errs int32
+	pln.OnError = func(_ net.Conn, err error) {
+		atomic.AddInt32(&errs, 1)
+	}
+	// a slow client does not block the other connections
+	slow := dial(pln, ``)
+	start := time.Now()
+	client := dial(pln, "PROXY TCP4 203.0.113.7 127.0.0.1 1234 80\r\nhello")
+	assert.Equal(t, `203.0.113.7:1234|hello`, accept(pln))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	client.Close()
+
+	// a trusted source without header is closed without being accepted
+	for _, c := range []net.Conn{slow, dial(pln, `hello`)} {
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		_, err = c.Read(make([]byte, 1))
+		assert.ErrorIs(t, err, io.EOF)
+		c.Close()
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&errs))
+	pln.Close()
+	_, err = pln.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+
+	// connections from untrusted sources are passed through
+	pln = listen(`10.0.0.0/8`)
+	defer pln.Close()
+	client = dial(pln, "PROXY TCP4 203.0.113.7 127.0.0.1 1234 80\r\nhello")
+	r := accept(pln)
+	assert.True(t, strings.HasPrefix(r, `127.0.0.1:`))
+	assert.True(t, strings.HasSuffix(r, `|PROXY TCP4 203.0.113.7 127.0.0.1 1234 80`+"\r\n"+`hello`))
+	client.Close()
+}
+
+func TestProxyProtocolListenerClosePending(t *testing.T) {
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	assert.NoError(t, err)
+	pln, err := NewProxyProtocolListener(ln, time.Minute)
+	assert.NoError(t, err)
+	var errs int32
+	pln.OnError = func(_ net.Conn, err error) {
+		atomic.AddInt32(&errs, 1)
+	}
+	go pln.Accept()
+
+	// the connection waiting for its header is closed by Close
+	client, err := net.Dial(`tcp`, pln.Addr().String())
+	assert.NoError(t, err)
+	defer client.Close()
+	assert.Eventually(t, func() bool {
+		pln.mutex.Lock()
+		defer pln.mutex.Unlock()
+		return len(pln.pending) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, pln.Close())
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = client.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&errs))
+}
+
+func readAll(conn net.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	var b []byte
+	buf := make([]byte, 128)
+	for {
+		n, err := conn.Read(buf)
+		b = append(b, buf[:n]...)
+		if err != nil || bytes.HasSuffix(b, []byte(`hello`)) {
+			break
+		}
+	}
+	conn.Close()
+	return string(b)
+}
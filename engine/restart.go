@@ -0,0 +1,159 @@
+//go:build !windows
+// +build !windows
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DefaultRestartSignals are the signals watched by `WatchRestartSignal`.
+var DefaultRestartSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR2}
+
+// StartProcess starts a copy of the current process which inherits the listeners,
+// and waits until it is serving (see `NotifyReady`). The child process is killed if
+// it is not ready within timeout.
+func StartProcess(timeout time.Duration, listeners ...net.Listener) (*os.Process, error) {
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, ln := range listeners {
+		filer, ok := ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return nil, fmt.Errorf(`%w: can not get the file of listener %s`, ErrUnsupported, ln.Addr())
+		}
+		file, err := filer.File()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer ready.Close()
+	files = append(files, readyW)
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		EnvListenFDs+`=`+strconv.Itoa(len(listeners)),
+		EnvReadyFD+`=`+strconv.Itoa(listenFDsStart+len(listeners)),
+	)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	readyW.Close()
+	files = files[:len(files)-1]
+
+	readyCh := make(chan struct{})
+	exitCh := make(chan error, 1)
+	go func() {
+		io.Copy(io.Discard, ready)
+		close(readyCh)
+	}()
+	go func() {
+		exitCh <- cmd.Wait()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-readyCh:
+		// the pipe is also closed when the child process exits
+		select {
+		case err := <-exitCh:
+			return nil, fmt.Errorf(`the new process exited: %v`, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		return cmd.Process, nil
+	case err := <-exitCh:
+		return nil, fmt.Errorf(`the new process exited: %v`, err)
+	case <-timer.C:
+		cmd.Process.Kill()
+		return nil, errors.New(`the new process is not ready in ` + timeout.String())
+	}
+}
+
+// Restart starts a new process serving on the listeners of the engine, and then
+// shuts the engine down gracefully within timeout.
+// The engine keeps serving if the new process fails to start.
+func Restart(e Engine, timeout time.Duration) error {
+	listeners := rawListeners(e)
+	if len(listeners) == 0 {
+		return errors.New(`the engine is not listening`)
+	}
+	p, err := StartProcess(timeout, listeners...)
+	if err != nil {
+		return err
+	}
+	for _, ln := range listeners {
+		keepUnixSocket(ln)
+	}
+	log.Printf("new process %d is serving, shutting down the process %d\n", p.Pid, os.Getpid())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return e.Shutdown(ctx)
+}
+
+func rawListeners(e Engine) []net.Listener {
+	if m, ok := e.(*Multi); ok {
+		var listeners []net.Listener
+		for _, e := range m.Engines() {
+			listeners = append(listeners, rawListeners(e)...)
+		}
+		return listeners
+	}
+	if ln := e.Config().RawListener(); ln != nil {
+		return []net.Listener{ln}
+	}
+	return nil
+}
+
+// WatchRestartSignal calls `Restart` when one of the signals (default `DefaultRestartSignals`)
+// is received. The returned function stops watching.
+func WatchRestartSignal(e Engine, timeout time.Duration, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = DefaultRestartSignals
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				log.Printf("received signal %v, restarting\n", sig)
+				if err := Restart(e, timeout); err != nil {
+					log.Println(`restart failed:`, err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+package engine
+
+import (
+	"net"
+	"os"
+	"time"
+)
+
+var DefaultRestartSignals []os.Signal
+
+func StartProcess(timeout time.Duration, listeners ...net.Listener) (*os.Process, error) {
+	return nil, ErrUnsupported
+}
+
+func Restart(e Engine, timeout time.Duration) error {
+	return ErrUnsupported
+}
+
+func WatchRestartSignal(e Engine, timeout time.Duration, signals ...os.Signal) (stop func()) {
+	return func() {}
+}
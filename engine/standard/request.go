@@ -2,8 +2,10 @@ package standard
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"sync"
 
@@ -78,6 +80,13 @@ func (r *Request) Proto() string {
 }
 
 func (r *Request) RemoteAddress() string {
+	if len(r.request.RemoteAddr) == 0 || r.request.RemoteAddr == engine.UnixRemoteAddress {
+		if local, ok := r.request.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			if addr, ok := engine.UnixPeerAddress(local, nil); ok {
+				return addr
+			}
+		}
+	}
 	return r.request.RemoteAddr
 }
 
@@ -200,3 +209,7 @@ func (r *Request) SetHost(host string) {
 func (r *Request) StdRequest() *http.Request {
 	return r.request
 }
+
+func (r *Request) TLSConnectionState() *tls.ConnectionState {
+	return r.request.TLS
+}
@@ -2,10 +2,13 @@ package standard
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"sync"
 
 	"github.com/admpub/log"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/webx-top/echo/engine"
 	"github.com/webx-top/echo/logger"
@@ -18,6 +21,7 @@ type (
 		handler engine.Handler
 		logger  logger.Logger
 		pool    *pool
+		conns   *engine.ConnTracker
 	}
 
 	pool struct {
@@ -57,6 +61,7 @@ func NewWithConfig(c *engine.Config) (s *Server) {
 			Addr:         c.Address,
 		},
 		config: c,
+		conns:  engine.NewConnTracker(),
 		pool: &pool{
 			request: sync.Pool{
 				New: func() interface{} {
@@ -89,7 +94,21 @@ func NewWithConfig(c *engine.Config) (s *Server) {
 		}),
 		logger: log.GetLogger("echo"),
 	}
-	s.Handler = s
+	c.LimitConnsPerIP(s.conns.Reject)
+	s.ConnState = func(conn net.Conn, state http.ConnState) {
+		s.conns.SetState(conn, engine.ConnState(state))
+	}
+	if c.MaxRequestsPerConn > 0 {
+		s.ConnContext = func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey{}, conn)
+		}
+	}
+	if c.H2C && !c.DisableHTTP2 {
+		// handles both prior knowledge and `Upgrade: h2c` connections
+		s.Handler = h2c.NewHandler(s, &http2.Server{})
+	} else {
+		s.Handler = s
+	}
 	return
 }
 
@@ -101,20 +120,27 @@ func (s *Server) SetLogger(l logger.Logger) {
 	s.logger = l
 }
 
+// InitListener implements `engine.ListenerInitializer#InitListener` function.
+func (s *Server) InitListener() error {
+	if s.config.Listener != nil {
+		return nil
+	}
+	return s.config.InitListener()
+}
+
 // Start implements `engine.Server#Start` function.
 func (s *Server) Start() error {
-	if s.config.Listener == nil {
-		err := s.config.InitListener()
-		if err != nil {
-			return err
-		}
+	if err := s.InitListener(); err != nil {
+		return err
 	}
+	s.config.NotifyReady()
 	s.config.Print(`standard`)
 	return s.Serve(s.config.Listener)
 }
 
 // Stop implements `engine.Server#Stop` function.
 func (s *Server) Stop() error {
+	s.config.CloseCertManager()
 	if s.config.Listener == nil {
 		return nil
 	}
@@ -122,6 +148,7 @@ func (s *Server) Stop() error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.config.CloseCertManager()
 	return s.Server.Shutdown(ctx)
 }
 
@@ -129,8 +156,20 @@ func (s *Server) Config() *engine.Config {
 	return s.config
 }
 
+// Stats implements `engine.StatsProvider#Stats` function.
+func (s *Server) Stats() engine.ConnStats {
+	return s.conns.Stats()
+}
+
+type connContextKey struct{}
+
 // ServeHTTP implements `http.Handler` interface.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.config.MaxRequestsPerConn > 0 && r.ProtoMajor == 1 {
+		if conn, ok := r.Context().Value(connContextKey{}).(net.Conn); ok && s.conns.AddRequest(conn) >= s.config.MaxRequestsPerConn {
+			w.Header().Set(`Connection`, `close`)
+		}
+	}
 	// Request
 	req := s.pool.request.Get().(*Request)
 	reqHdr := s.pool.requestHeader.Get().(*Header)
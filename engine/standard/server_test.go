@@ -0,0 +1,222 @@
+package standard
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+
+	"github.com/webx-top/echo/engine"
+)
+
+func newH2CTestServer(t *testing.T) (*Server, string) {
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	assert.NoError(t, err)
+	s := New(``, engine.Listener(ln), engine.H2C(true))
+	s.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(req.Proto()))
+	}))
+	go s.Start()
+	return s, ln.Addr().String()
+}
+
+// newH2CClient returns a client which speaks HTTP/2 with prior knowledge over cleartext TCP.
+func newH2CClient() *http.Client {
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func TestH2C(t *testing.T) {
+	s, addr := newH2CTestServer(t)
+	defer s.Stop()
+
+	// prior knowledge
+	resp, err := newH2CClient().Get(`http://` + addr + `/`)
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, `HTTP/2.0`, string(b))
+	}
+
+	// HTTP/1.1 is still served
+	resp, err = http.Get(`http://` + addr + `/`)
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, `HTTP/1.1`, string(b))
+	}
+
+	// Upgrade: h2c
+	conn, err := net.Dial(`tcp`, addr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	settings := base64.RawURLEncoding.EncodeToString(nil)
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: "+addr+"\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: "+settings+"\r\n\r\n")
+	assert.NoError(t, err)
+	resp, err = http.ReadResponse(bufio.NewReader(conn), nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		assert.True(t, strings.EqualFold(resp.Header.Get(`Upgrade`), `h2c`))
+	}
+}
+
+func TestUnixSocketRemoteAddress(t *testing.T) {
+	dir, err := os.MkdirTemp(``, `echo`)
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, `app.sock`)
+	s := New(`unix://` + path)
+	s.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		res.Write([]byte(req.RemoteAddress()))
+	}))
+	go s.Start()
+	defer s.Stop()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, `unix`, path)
+		},
+	}}
+	var body string
+	assert.Eventually(t, func() bool {
+		resp, err := client.Get(`http://unix/`)
+		if err != nil {
+			return false
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body = string(b)
+		return true
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, engine.UnixRemoteAddress, body)
+}
+
+func TestConnLimits(t *testing.T) {
+	s := New(`127.0.0.1:0`, engine.MaxConnsPerIP(1), engine.MaxRequestsPerConn(2))
+	s.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		res.Write([]byte(`ok`))
+	}))
+	assert.NoError(t, s.InitListener())
+	go s.Start()
+	defer s.Stop()
+	addr := s.config.Listener.Addr().String()
+
+	conn, err := net.Dial(`tcp`, addr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	request := func() *http.Response {
+		io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+		resp, err := http.ReadResponse(r, nil)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+	resp := request()
+	assert.False(t, resp.Close)
+	assert.Eventually(t, func() bool {
+		stats := s.Stats()
+		return stats.Open == 1 && stats.Idle == 1 && stats.PerIP[`127.0.0.1`] == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// the second connection from the same IP is rejected
+	conn2, err := net.Dial(`tcp`, addr)
+	if assert.NoError(t, err) {
+		io.WriteString(conn2, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+		_, err = http.ReadResponse(bufio.NewReader(conn2), nil)
+		assert.Error(t, err)
+		conn2.Close()
+	}
+	assert.Equal(t, uint64(1), s.Stats().Rejected)
+
+	// the connection is closed after MaxRequestsPerConn requests
+	resp = request()
+	assert.True(t, resp.Close)
+	assert.Eventually(t, func() bool {
+		return s.Stats().Open == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), s.Stats().Accepted)
+}
+
+func writeTestCert(t *testing.T, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: `127.0.0.1`},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: `CERTIFICATE`, Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: `EC PRIVATE KEY`, Bytes: keyDER}), 0600))
+}
+
+func TestConnLimitsTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, `cert.pem`), filepath.Join(dir, `key.pem`)
+	writeTestCert(t, certFile, keyFile)
+	s := NewWithTLS(`127.0.0.1:0`, certFile, keyFile, engine.MaxConnsPerIP(1))
+	s.SetHandler(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		r := req.StdRequest()
+		if r.TLS == nil {
+			res.Write([]byte(`no tls`))
+			return
+		}
+		res.Write([]byte(r.Proto + ` ` + tls.VersionName(r.TLS.Version)))
+	}))
+	assert.NoError(t, s.InitListener())
+	go s.Start()
+	defer s.Stop()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	defer client.CloseIdleConnections()
+	resp, err := client.Get(`https://` + s.config.Listener.Addr().String() + `/`)
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, `HTTP/2.0 TLS 1.3`, string(b))
+	}
+	assert.Equal(t, 1, s.Stats().PerIP[`127.0.0.1`])
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package engine
+
+import (
+	"sync"
+	"syscall"
+)
+
+var umaskMutex sync.Mutex
+
+// withUmask calls fn with the umask of the process set to mask, or unchanged if mask is negative.
+// The umask is process-wide, so the files created concurrently by other goroutines get it too.
+func withUmask(mask int, fn func() error) error {
+	if mask < 0 {
+		return fn()
+	}
+	umaskMutex.Lock()
+	defer umaskMutex.Unlock()
+	old := syscall.Umask(mask)
+	defer syscall.Umask(old)
+	return fn()
+}
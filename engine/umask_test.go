@@ -0,0 +1,41 @@
+//go:build !windows
+// +build !windows
+
+package engine
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocketUmask(t *testing.T) {
+	dir, err := os.MkdirTemp(``, `echo`)
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, `app.sock`)
+
+	c := &Config{Address: `unix://` + path, UnixSocketMode: 0660}
+	assert.Equal(t, 0117, c.unixSocketUmask())
+	assert.Equal(t, -1, (&Config{Address: `unix://` + path}).unixSocketUmask())
+	assert.Equal(t, -1, (&Config{Address: `unix://@app`, UnixSocketMode: 0660}).unixSocketUmask())
+	assert.Equal(t, -1, (&Config{Address: `:8080`, UnixSocketMode: 0660}).unixSocketUmask())
+
+	// the socket file is created with the mode instead of the permissions of the process umask
+	old := syscall.Umask(0)
+	defer syscall.Umask(old)
+	var ln net.Listener
+	assert.NoError(t, withUmask(c.unixSocketUmask(), func() (err error) {
+		ln, err = net.Listen(`unix`, path)
+		return
+	}))
+	defer ln.Close()
+	fi, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), fi.Mode().Perm())
+	assert.Equal(t, 0, syscall.Umask(0))
+}
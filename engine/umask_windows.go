@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package engine
+
+// withUmask calls fn. There is no umask on Windows.
+func withUmask(mask int, fn func() error) error {
+	return fn()
+}
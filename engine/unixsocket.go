@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// UnixRemoteAddress is the remote address of the requests from unix domain socket peers.
+const UnixRemoteAddress = `@`
+
+// IsUnixScheme reports whether the scheme of `NewListener` is a unix domain socket.
+func IsUnixScheme(scheme string) bool {
+	return strings.HasPrefix(scheme, `unix`)
+}
+
+// IsAbstractUnixSocket reports whether the address is a Linux abstract socket (`unix://@name`),
+// which has no file in the file system.
+func IsAbstractUnixSocket(address string) bool {
+	return strings.HasPrefix(address, `@`)
+}
+
+// IsUnixRemoteAddress reports whether the remote address is the one of a unix domain socket peer.
+func IsUnixRemoteAddress(remoteAddress string) bool {
+	return strings.HasPrefix(remoteAddress, UnixRemoteAddress) || strings.HasPrefix(remoteAddress, `/`)
+}
+
+// UnixPeerAddress returns `UnixRemoteAddress` for the unnamed peers of unix domain sockets.
+func UnixPeerAddress(local net.Addr, remote net.Addr) (string, bool) {
+	if _, ok := local.(*net.UnixAddr); !ok {
+		return ``, false
+	}
+	if remote != nil {
+		if addr := remote.String(); len(addr) > 0 && addr != `<nil>` {
+			return addr, true
+		}
+	}
+	return UnixRemoteAddress, true
+}
+
+// RemoveStaleUnixSocket removes the socket file left by a process which is not running any more.
+// It returns an error if another process is listening on it.
+func RemoveStaleUnixSocket(path string) error {
+	if IsAbstractUnixSocket(path) {
+		return nil
+	}
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf(`%s exists and is not a unix socket`, path)
+	}
+	conn, err := net.DialTimeout(`unix`, path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf(`%s: %w`, path, syscall.EADDRINUSE)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) && !errors.Is(err, syscall.ENOENT) {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// ParseOwner parses `user:group`, `user` or `:group`. Both names and numeric ids are accepted.
+// An omitted part is returned as -1.
+func ParseOwner(owner string) (uid int, gid int, err error) {
+	uid, gid = -1, -1
+	userName, groupName, _ := strings.Cut(owner, `:`)
+	if len(userName) > 0 {
+		if uid, err = strconv.Atoi(userName); err != nil {
+			var u *user.User
+			if u, err = user.Lookup(userName); err != nil {
+				return
+			}
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return
+			}
+		}
+	}
+	if len(groupName) > 0 {
+		if gid, err = strconv.Atoi(groupName); err != nil {
+			var g *user.Group
+			if g, err = user.LookupGroup(groupName); err != nil {
+				return
+			}
+			gid, err = strconv.Atoi(g.Gid)
+		}
+	}
+	return
+}
+
+// unixSocketUmask returns the umask which creates the socket file of the address with the
+// permissions of `UnixSocketMode`, so that it never has looser permissions between its
+// creation and `setupUnixSocket`. It returns -1 if there is nothing to change.
+func (c *Config) unixSocketUmask() int {
+	if c.UnixSocketMode == 0 {
+		return -1
+	}
+	scheme, address, ok := strings.Cut(c.Address, `://`)
+	if !ok || !IsUnixScheme(scheme) || IsAbstractUnixSocket(address) {
+		return -1
+	}
+	return int(0777 &^ c.UnixSocketMode.Perm())
+}
+
+// setupUnixSocket applies `UnixSocketMode` and `UnixSocketOwner` to the socket file.
+func (c *Config) setupUnixSocket(ln net.Listener) error {
+	addr, ok := ln.Addr().(*net.UnixAddr)
+	if !ok || IsAbstractUnixSocket(addr.Name) {
+		return nil
+	}
+	if c.UnixSocketMode != 0 {
+		if err := os.Chmod(addr.Name, c.UnixSocketMode); err != nil {
+			return err
+		}
+	}
+	if len(c.UnixSocketOwner) > 0 {
+		uid, gid, err := ParseOwner(c.UnixSocketOwner)
+		if err != nil {
+			return err
+		}
+		if err := os.Lchown(addr.Name, uid, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepUnixSocket keeps the socket file when the listener is closed,
+// so that the process which inherits it can keep serving on it.
+func keepUnixSocket(ln net.Listener) {
+	if uln, ok := ln.(*net.UnixListener); ok {
+		uln.SetUnlinkOnClose(false)
+	}
+}
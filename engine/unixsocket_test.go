@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixSocketListener(t *testing.T) {
+	dir, err := os.MkdirTemp(``, `echo`) // the path of t.TempDir() may exceed the length limit of socket paths
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, `app.sock`)
+
+	// a stale socket file
+	stale, err := net.Listen(`unix`, path)
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	c := &Config{Address: `unix://` + path, UnixSocketMode: 0660}
+	assert.NoError(t, c.InitListener())
+	fi, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), fi.Mode().Perm())
+
+	// a socket in use is not removed
+	_, err = NewListener(`unix://`+path, false)
+	assert.Error(t, err)
+
+	go func() {
+		for {
+			conn, err := c.Listener.Accept()
+			if err != nil {
+				return
+			}
+			remote, _ := UnixPeerAddress(conn.LocalAddr(), conn.RemoteAddr())
+			conn.Write([]byte(remote))
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial(`unix`, path)
+	assert.NoError(t, err)
+	b := make([]byte, 8)
+	n, _ := conn.Read(b)
+	conn.Close()
+	assert.Equal(t, UnixRemoteAddress, string(b[:n]))
+	assert.True(t, IsUnixRemoteAddress(string(b[:n])))
+
+	assert.NoError(t, c.Listener.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseOwner(t *testing.T) {
+	uid, gid, err := ParseOwner(`1000:100`)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1000, 100}, []int{uid, gid})
+	uid, gid, err = ParseOwner(`:100`)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{-1, 100}, []int{uid, gid})
+	_, _, err = ParseOwner(`no-such-user-x`)
+	assert.Error(t, err)
+}
@@ -240,6 +240,7 @@ func NewPanicError(recovered interface{}, err error, debugAndDisableStackAll ...
 type PanicError struct {
 	error
 	Raw             interface{}
+	RequestID       string
 	Traces          []*Trace
 	Snippets        []*SnippetGroup
 	debug           bool
@@ -309,10 +310,13 @@ func (p *PanicError) Error() string {
 }
 
 func (p *PanicError) String() string {
+	e := p.error.Error()
+	if len(p.RequestID) > 0 {
+		e = `[request_id: ` + p.RequestID + `] ` + e
+	}
 	if len(p.Snippets) == 0 {
-		return p.error.Error()
+		return e
 	}
-	e := p.error.Error()
 	for _, sg := range p.Snippets {
 		f := sg.Path + ":" + strconv.Itoa(p.Traces[sg.Index].Line)
 		if pos := strings.Index(e, f); pos > -1 {
@@ -328,13 +332,17 @@ func (p *PanicError) String() string {
 }
 
 func (p *PanicError) HTML() template.HTML {
+	var requestID string
+	if len(p.RequestID) > 0 {
+		requestID = `<p class="panic-request-id">Request ID: <code>` + html.EscapeString(p.RequestID) + `</code></p>`
+	}
 	if len(p.Snippets) == 0 {
-		return template.HTML(`<pre>` + p.error.Error() + `</pre>`)
+		return template.HTML(requestID + `<pre>` + html.EscapeString(p.error.Error()) + `</pre>`)
 	}
-	table := "<style>.panic-table-snippet td.left{width:100px;text-align:right}.panic-table-trace td.left{width:50%}</style>"
+	table := requestID + "<style>.panic-table-snippet td.left{width:100px;text-align:right}.panic-table-trace td.left{width:50%}</style>"
 	for _, sg := range p.Snippets {
 		table += `<table class="table table-bordered panic-table panic-table-snippet">`
-		table += `<thead><tr><th colspan="2">` + sg.Path + `</th></tr></thead>`
+		table += `<thead><tr><th colspan="2">` + html.EscapeString(sg.Path) + `</th></tr></thead>`
 		table += `<tbody>`
 		table += sg.TableRow()
 		table += `</tbody>`
@@ -345,12 +353,13 @@ func (p *PanicError) HTML() template.HTML {
 	table += `<tbody>`
 	for _, ts := range p.Traces {
 		f := html.EscapeString(ts.File) + `:` + strconv.Itoa(ts.Line)
+		fn := html.EscapeString(ts.Func)
 		table += `<tr><td class='left'>`
 		if ts.HasErr {
 			table += `<strong>` + f + `</strong>`
-			table += `</td><td class='right'><strong>` + ts.Func + `</strong>`
+			table += `</td><td class='right'><strong>` + fn + `</strong>`
 		} else {
-			table += f + `</td><td class='right'>` + ts.Func
+			table += f + `</td><td class='right'>` + fn
 		}
 		table += `</td></tr>`
 	}
@@ -413,6 +422,12 @@ func (p *PanicError) SetError(err error) *PanicError {
 	return p
 }
 
+// SetRequestID sets the ID of the request which panicked.
+func (p *PanicError) SetRequestID(id string) *PanicError {
+	p.RequestID = id
+	return p
+}
+
 func (p *PanicError) SetErrorString(errStr string) *PanicError {
 	p.error = errors.New(errStr)
 	return p
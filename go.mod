@@ -6,6 +6,7 @@ toolchain go1.21.6
 
 require (
 	github.com/admpub/boltstore v1.1.1
+	github.com/admpub/confl v0.2.4
 	github.com/admpub/decimal v1.3.1
 	github.com/admpub/errors v0.8.2
 	github.com/admpub/events v1.3.6
@@ -48,6 +49,7 @@ require (
 	github.com/webx-top/tagfast v0.0.1
 	github.com/webx-top/validation v0.0.3
 	golang.org/x/crypto v0.23.0
+	golang.org/x/net v0.25.0
 	golang.org/x/sync v0.7.0
 	golang.org/x/time v0.5.0
 	gopkg.in/redis.v5 v5.2.9
@@ -57,7 +59,6 @@ require (
 	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/CloudyKit/fastprinter v0.0.0-20200109182630-33d98a066a53 // indirect
 	github.com/admpub/color v1.8.1 // indirect
-	github.com/admpub/confl v0.2.4 // indirect
 	github.com/admpub/pp v0.0.7 // indirect
 	github.com/admpub/randomize v0.0.2 // indirect
 	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
@@ -82,7 +83,6 @@ require (
 	github.com/volatiletech/inflect v0.0.1 // indirect
 	github.com/volatiletech/strmangle v0.0.6 // indirect
 	github.com/yuin/gopher-lua v0.0.0-20200816102855-ee81675732da // indirect
-	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/oauth2 v0.20.0 // indirect
 	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
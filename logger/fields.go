@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// Fields are the key-value pairs added to every log entry.
+	Fields map[string]interface{}
+
+	// FieldsLogger is implemented by the loggers which support structured fields.
+	FieldsLogger interface {
+		WithFields(Fields) Logger
+	}
+)
+
+// WithFields returns a logger which adds the fields to every log entry.
+// The fields are prepended to the messages as `key=value` if the logger
+// does not implement `FieldsLogger`.
+func WithFields(l Logger, fields Fields) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	if fl, ok := l.(FieldsLogger); ok {
+		return fl.WithFields(fields)
+	}
+	return newFieldsLogger(l, fields)
+}
+
+func newFieldsLogger(l Logger, fields Fields) *fieldsLogger {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var prefix string
+	for _, key := range keys {
+		prefix += key + `=` + fmt.Sprint(fields[key]) + ` `
+	}
+	return &fieldsLogger{
+		Logger:       l,
+		fields:       fields,
+		prefix:       prefix,
+		formatPrefix: strings.ReplaceAll(prefix, `%`, `%%`),
+	}
+}
+
+type fieldsLogger struct {
+	Logger
+	fields       Fields
+	prefix       string
+	formatPrefix string
+}
+
+// WithFields merges the fields with the fields of the logger.
+func (f *fieldsLogger) WithFields(fields Fields) Logger {
+	merged := make(Fields, len(f.fields)+len(fields))
+	for key, value := range f.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return newFieldsLogger(f.Logger, merged)
+}
+
+func (f *fieldsLogger) args(args []interface{}) []interface{} {
+	return append([]interface{}{f.prefix}, args...)
+}
+
+func (f *fieldsLogger) Debug(args ...interface{}) {
+	f.Logger.Debug(f.args(args)...)
+}
+
+func (f *fieldsLogger) Debugf(format string, args ...interface{}) {
+	f.Logger.Debugf(f.formatPrefix+format, args...)
+}
+
+func (f *fieldsLogger) Info(args ...interface{}) {
+	f.Logger.Info(f.args(args)...)
+}
+
+func (f *fieldsLogger) Infof(format string, args ...interface{}) {
+	f.Logger.Infof(f.formatPrefix+format, args...)
+}
+
+func (f *fieldsLogger) Warn(args ...interface{}) {
+	f.Logger.Warn(f.args(args)...)
+}
+
+func (f *fieldsLogger) Warnf(format string, args ...interface{}) {
+	f.Logger.Warnf(f.formatPrefix+format, args...)
+}
+
+func (f *fieldsLogger) Error(args ...interface{}) {
+	f.Logger.Error(f.args(args)...)
+}
+
+func (f *fieldsLogger) Errorf(format string, args ...interface{}) {
+	f.Logger.Errorf(f.formatPrefix+format, args...)
+}
+
+func (f *fieldsLogger) Fatal(args ...interface{}) {
+	f.Logger.Fatal(f.args(args)...)
+}
+
+func (f *fieldsLogger) Fatalf(format string, args ...interface{}) {
+	f.Logger.Fatalf(f.formatPrefix+format, args...)
+}
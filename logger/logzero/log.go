@@ -13,10 +13,11 @@ import (
 
 var (
 	// CallerSkipFrameCount caller skip frame count
-	CallerSkipFrameCount               = 4
-	WriterDefaultLevel                 = zerolog.InfoLevel
-	global               *Logger       = New()
-	_                    logger.Logger = &Logger{}
+	CallerSkipFrameCount                     = 4
+	WriterDefaultLevel                       = zerolog.InfoLevel
+	global               *Logger             = New()
+	_                    logger.Logger       = &Logger{}
+	_                    logger.FieldsLogger = &Logger{}
 )
 
 func SetGlobal(l *Logger) {
@@ -138,6 +139,15 @@ func (a *Logger) Fatalf(t string, s ...interface{}) {
 	a.Logger.Fatal().Msgf(t, s...)
 }
 
+// WithFields returns a logger which adds the fields to every log entry.
+func (a *Logger) WithFields(fields logger.Fields) logger.Logger {
+	l := a.Logger.With().Fields(map[string]interface{}(fields)).Logger()
+	clone := *a
+	clone.Logger = &l
+	clone.subs = make(map[string]*Logger)
+	return &clone
+}
+
 func (a *Logger) GetLogger(category string, writers ...io.Writer) *Logger {
 	a.mutex.RLock()
 	subLogger, ok := a.subs[category]
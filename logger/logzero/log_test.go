@@ -3,6 +3,7 @@ package logzero_test
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/webx-top/echo/logger/logzero"
@@ -19,7 +20,7 @@ func TestLog(t *testing.T) {
 	log.Println(`test log message`)
 	log.SetOutput(logzero.GetLogger(`test`))
 	log.Println(`test log message(test)`)
-	f, err := os.OpenFile(`./app.log`, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
+	f, err := os.OpenFile(filepath.Join(t.TempDir(), `app.log`), os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.ModePerm)
 	if err != nil {
 		panic(err)
 	}
@@ -27,4 +28,5 @@ func TestLog(t *testing.T) {
 
 	log.SetOutput(logzero.GetLogger(`test2`, f))
 	log.Println(`test log message(test)`)
+	log.SetOutput(os.Stderr)
 }
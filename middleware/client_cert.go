@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"path"
+	"strings"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+)
+
+type (
+	// ClientCertConfig defines the config for ClientCert middleware.
+	// Use it on a `Group` to apply different allow-lists to different routes.
+	ClientCertConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper
+
+		// Optional allows the requests without client certificate.
+		// The allow-lists and the validator are only applied when a certificate is present.
+		Optional bool
+
+		// Allow-lists matched with `path.Match` patterns, e.g. "*.example.com"
+		// or "spiffe://example.org/ns/prod/*". A certificate is allowed if any of
+		// its names matches any pattern. Empty lists allow all verified certificates.
+		AllowCommonNames []string
+		AllowDNSNames    []string
+		AllowSPIFFEIDs   []string
+
+		// Validator is an additional check of the identity.
+		Validator func(*ClientCertIdentity, echo.Context) error
+
+		// ContextKey stores the identity in `echo.Context`.
+		// Optional. Default value "clientCertIdentity".
+		ContextKey string
+	}
+
+	// ClientCertIdentity is the identity of a verified client certificate.
+	ClientCertIdentity struct {
+		CommonName     string
+		Subject        string
+		Issuer         string
+		SerialNumber   string
+		Fingerprint    string // SHA-256 of the DER certificate in hex
+		DNSNames       []string
+		EmailAddresses []string
+		IPAddresses    []string
+		URIs           []string
+		SPIFFEID       string
+		Certificate    *x509.Certificate
+		Chain          []*x509.Certificate
+	}
+)
+
+var (
+	// DefaultClientCertConfig is the default ClientCert middleware config.
+	DefaultClientCertConfig = ClientCertConfig{
+		Skipper:    echo.DefaultSkipper,
+		ContextKey: `clientCertIdentity`,
+	}
+)
+
+// NewClientCertIdentity extracts the identity from a verified certificate chain.
+func NewClientCertIdentity(chain []*x509.Certificate) *ClientCertIdentity {
+	cert := chain[0]
+	sum := sha256.Sum256(cert.Raw)
+	id := &ClientCertIdentity{
+		CommonName:     cert.Subject.CommonName,
+		Subject:        cert.Subject.String(),
+		Issuer:         cert.Issuer.String(),
+		SerialNumber:   cert.SerialNumber.String(),
+		Fingerprint:    hex.EncodeToString(sum[:]),
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		Certificate:    cert,
+		Chain:          chain,
+	}
+	for _, ip := range cert.IPAddresses {
+		id.IPAddresses = append(id.IPAddresses, ip.String())
+	}
+	for _, u := range cert.URIs {
+		uri := u.String()
+		id.URIs = append(id.URIs, uri)
+		if u.Scheme == `spiffe` && len(id.SPIFFEID) == 0 {
+			id.SPIFFEID = uri
+		}
+	}
+	return id
+}
+
+// ClientCert returns a middleware which requires a verified client certificate
+// and stores its identity in the context. See `ClientCertIdentityFrom()`.
+func ClientCert() echo.MiddlewareFuncd {
+	return ClientCertWithConfig(DefaultClientCertConfig)
+}
+
+// ClientCertWithConfig returns a ClientCert middleware with config.
+// It sends "401 - Unauthorized" when no verified certificate is presented and
+// "403 - Forbidden" when the certificate is not allowed.
+func ClientCertWithConfig(config ClientCertConfig) echo.MiddlewareFuncd {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultClientCertConfig.Skipper
+	}
+	if len(config.ContextKey) == 0 {
+		config.ContextKey = DefaultClientCertConfig.ContextKey
+	}
+	return func(next echo.Handler) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next.Handle(c)
+			}
+			state := engine.TLSConnectionState(c.Request())
+			// only verified chains are trusted (ClientAuth must be VerifyClientCertIfGiven or RequireAndVerifyClientCert)
+			if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+				if config.Optional {
+					return next.Handle(c)
+				}
+				return echo.ErrUnauthorized
+			}
+			id := NewClientCertIdentity(state.VerifiedChains[0])
+			if !config.allowed(id) {
+				return echo.ErrForbidden
+			}
+			if config.Validator != nil {
+				if err := config.Validator(id, c); err != nil {
+					return err
+				}
+			}
+			c.Set(config.ContextKey, id)
+			return next.Handle(c)
+		}
+	}
+}
+
+func (config *ClientCertConfig) allowed(id *ClientCertIdentity) bool {
+	if len(config.AllowCommonNames) == 0 && len(config.AllowDNSNames) == 0 && len(config.AllowSPIFFEIDs) == 0 {
+		return true
+	}
+	return matchAny(config.AllowCommonNames, id.CommonName) ||
+		matchAny(config.AllowDNSNames, id.DNSNames...) ||
+		matchAny(config.AllowSPIFFEIDs, id.SPIFFEID)
+}
+
+func matchAny(patterns []string, names ...string) bool {
+	for _, name := range names {
+		if len(name) == 0 {
+			continue
+		}
+		for _, pattern := range patterns {
+			if strings.EqualFold(pattern, name) {
+				return true
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClientCertIdentityFrom returns the identity stored by the ClientCert middleware, or nil.
+func ClientCertIdentityFrom(c echo.Context, contextKey ...string) *ClientCertIdentity {
+	key := DefaultClientCertConfig.ContextKey
+	if len(contextKey) > 0 && len(contextKey[0]) > 0 {
+		key = contextKey[0]
+	}
+	id, _ := c.Get(key).(*ClientCertIdentity)
+	return id
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+func TestClientCert(t *testing.T) {
+	spiffe, _ := url.Parse(`spiffe://example.org/ns/prod/sa/api`)
+	cert := &x509.Certificate{
+		Raw:          []byte(`der`),
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: `api`, Organization: []string{`webx`}},
+		DNSNames:     []string{`api.example.org`},
+		URIs:         []*url.URL{spiffe},
+	}
+	e := echo.New()
+	serve := func(config ClientCertConfig, state *tls.ConnectionState) (int, *ClientCertIdentity) {
+		req := test.NewStdRequest(http.MethodGet, `/`)
+		req.TLS = state
+		c := echo.NewContext(test.WrapRequest(req), test.WrapResponse(req, test.NewStdResponse()), e)
+		var id *ClientCertIdentity
+		err := ClientCertWithConfig(config)(echo.HandlerFunc(func(c echo.Context) error {
+			id = ClientCertIdentityFrom(c)
+			return nil
+		})).Handle(c)
+		if he, ok := err.(*echo.HTTPError); ok {
+			return he.Code, id
+		}
+		return http.StatusOK, id
+	}
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	unverified := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+
+	code, id := serve(ClientCertConfig{}, verified)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `api`, id.CommonName)
+	assert.Equal(t, `CN=api,O=webx`, id.Subject)
+	assert.Equal(t, `7`, id.SerialNumber)
+	assert.Equal(t, `spiffe://example.org/ns/prod/sa/api`, id.SPIFFEID)
+
+	code, _ = serve(ClientCertConfig{}, nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = serve(ClientCertConfig{}, unverified)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, id = serve(ClientCertConfig{Optional: true}, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, id)
+
+	code, _ = serve(ClientCertConfig{AllowSPIFFEIDs: []string{`spiffe://example.org/ns/prod/sa/*`}}, verified)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = serve(ClientCertConfig{AllowDNSNames: []string{`*.example.org`}}, verified)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = serve(ClientCertConfig{AllowSPIFFEIDs: []string{`spiffe://example.org/ns/dev/*`}, AllowCommonNames: []string{`web`}}, verified)
+	assert.Equal(t, http.StatusForbidden, code)
+}
@@ -2,10 +2,12 @@ package middleware
 
 import (
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
 )
 
 type (
@@ -15,9 +17,21 @@ type (
 		Skipper echo.Skipper
 
 		// AllowOrigin defines a list of origins that may access the resource.
+		// An origin can contain wildcards, e.g. `https://*.example.com` or `http://localhost:*`.
 		// Optional with default value as []string{"*"}.
 		AllowOrigins []string
 
+		// AllowOriginPatterns defines a list of regular expressions of the origins
+		// that may access the resource, e.g. `^https://[a-z]+\.example\.(com|org)$`.
+		// Optional with default value as []string{}.
+		AllowOriginPatterns []string
+
+		// AllowOriginFunc is a custom function to validate the origin, e.g. from the
+		// origins of the tenants in the database. It is called when the origin does
+		// not match `AllowOrigins` and `AllowOriginPatterns`.
+		// Optional.
+		AllowOriginFunc func(c echo.Context, origin string) (bool, error)
+
 		// AllowMethods defines a list methods allowed when accessing the resource.
 		// This is used in response to a preflight request.
 		// Optional with default value as `DefaultCORSConfig.AllowMethods`.
@@ -32,9 +46,25 @@ type (
 		// can be exposed when the credentials flag is true. When used as part of
 		// a response to a preflight request, this indicates whether or not the
 		// actual request can be made using credentials.
+		// It is not sent to the origins allowed by "*" in `AllowOrigins`, which
+		// would allow any website to read the responses with the credentials of
+		// its users, unless `UnsafeWildcardOriginWithAllowCredentials` is true.
 		// Optional with default value as false.
 		AllowCredentials bool
 
+		// UnsafeWildcardOriginWithAllowCredentials sends the origin instead of "*"
+		// together with `AllowCredentials`, so that any origin may access the
+		// resource with the credentials. This is insecure and should only be used
+		// for the resources which are public regardless of the credentials.
+		// Optional with default value as false.
+		UnsafeWildcardOriginWithAllowCredentials bool
+
+		// AllowPrivateNetwork allows the requests from public websites to the
+		// private network, by responding to the `Access-Control-Request-Private-Network`
+		// header of the preflight requests (Private Network Access).
+		// Optional with default value as false.
+		AllowPrivateNetwork bool
+
 		// ExposeHeaders defines a whitelist headers that clients are allowed to
 		// access.
 		// Optional with default value as []string{}.
@@ -69,18 +99,63 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 	if config.Skipper == nil {
 		config.Skipper = DefaultCORSConfig.Skipper
 	}
-	if len(config.AllowOrigins) == 0 {
+	if len(config.AllowOrigins) == 0 && len(config.AllowOriginPatterns) == 0 && config.AllowOriginFunc == nil {
 		config.AllowOrigins = DefaultCORSConfig.AllowOrigins
 	}
 	if len(config.AllowMethods) == 0 {
 		config.AllowMethods = DefaultCORSConfig.AllowMethods
 	}
-	allowOrigins := strings.Join(config.AllowOrigins, ",")
+	var (
+		allowAll     bool
+		exactOrigins = map[string]struct{}{}
+		patterns     []*regexp.Regexp
+	)
+	for _, origin := range config.AllowOrigins {
+		switch {
+		case origin == "*":
+			allowAll = true
+		case strings.Contains(origin, "*"):
+			patterns = append(patterns, wildcardOriginRegexp(origin))
+		default:
+			exactOrigins[strings.ToLower(origin)] = struct{}{}
+		}
+	}
+	for _, pattern := range config.AllowOriginPatterns {
+		patterns = append(patterns, regexp.MustCompile(pattern))
+	}
+	reflectAll := allowAll && config.AllowCredentials && config.UnsafeWildcardOriginWithAllowCredentials
+	// the response depends on the Origin header unless "*" is sent to all the origins
+	varyOrigin := !allowAll || reflectAll
 	allowMethods := strings.Join(config.AllowMethods, ",")
 	allowHeaders := strings.Join(config.AllowHeaders, ",")
 	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
 	maxAge := strconv.Itoa(config.MaxAge)
 
+	allowOrigin := func(c echo.Context, origin string) (string, error) {
+		if reflectAll {
+			return origin, nil
+		}
+		if allowAll {
+			return "*", nil
+		}
+		if _, ok := exactOrigins[strings.ToLower(origin)]; ok {
+			return origin, nil
+		}
+		for _, re := range patterns {
+			if re.MatchString(origin) {
+				return origin, nil
+			}
+		}
+		if config.AllowOriginFunc != nil {
+			ok, err := config.AllowOriginFunc(c, origin)
+			if err != nil || !ok {
+				return "", err
+			}
+			return origin, nil
+		}
+		return "", nil
+	}
+
 	return func(next echo.Handler) echo.Handler {
 		return echo.HandlerFunc(func(c echo.Context) error {
 			if config.Skipper(c) {
@@ -88,12 +163,26 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 			}
 			req := c.Request()
 			header := c.Response().Header()
+			origin := req.Header().Get(echo.HeaderOrigin)
+			preflight := req.Method() == echo.OPTIONS && len(req.Header().Get(echo.HeaderAccessControlRequestMethod)) > 0
 
 			// Simple request
-			if req.Method() != echo.OPTIONS {
-				header.Add(echo.HeaderVary, echo.HeaderOrigin)
-				header.Set(echo.HeaderAccessControlAllowOrigin, allowOrigins)
-				if config.AllowCredentials {
+			if !preflight {
+				if varyOrigin {
+					addVary(header, echo.HeaderOrigin)
+				}
+				if origin == "" {
+					return next.Handle(c)
+				}
+				allowed, err := allowOrigin(c, origin)
+				if err != nil {
+					return err
+				}
+				if allowed == "" {
+					return next.Handle(c)
+				}
+				header.Set(echo.HeaderAccessControlAllowOrigin, allowed)
+				if config.AllowCredentials && allowed != "*" {
 					header.Set(echo.HeaderAccessControlAllowCredentials, "true")
 				}
 				if exposeHeaders != "" {
@@ -103,12 +192,24 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 			}
 
 			// Preflight request
-			header.Add(echo.HeaderVary, echo.HeaderOrigin)
-			header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
-			header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
-			header.Set(echo.HeaderAccessControlAllowOrigin, allowOrigins)
+			if varyOrigin {
+				addVary(header, echo.HeaderOrigin)
+			}
+			addVary(header, echo.HeaderAccessControlRequestMethod)
+			addVary(header, echo.HeaderAccessControlRequestHeaders)
+			if config.AllowPrivateNetwork {
+				addVary(header, echo.HeaderAccessControlRequestPrivateNetwork)
+			}
+			allowed, err := allowOrigin(c, origin)
+			if err != nil {
+				return err
+			}
+			if allowed == "" {
+				return c.NoContent(http.StatusNoContent)
+			}
+			header.Set(echo.HeaderAccessControlAllowOrigin, allowed)
 			header.Set(echo.HeaderAccessControlAllowMethods, allowMethods)
-			if config.AllowCredentials {
+			if config.AllowCredentials && allowed != "*" {
 				header.Set(echo.HeaderAccessControlAllowCredentials, "true")
 			}
 			if allowHeaders != "" {
@@ -119,6 +220,9 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 					header.Set(echo.HeaderAccessControlAllowHeaders, h)
 				}
 			}
+			if config.AllowPrivateNetwork && req.Header().Get(echo.HeaderAccessControlRequestPrivateNetwork) == "true" {
+				header.Set(echo.HeaderAccessControlAllowPrivateNetwork, "true")
+			}
 			if config.MaxAge > 0 {
 				header.Set(echo.HeaderAccessControlMaxAge, maxAge)
 			}
@@ -126,3 +230,26 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 		})
 	}
 }
+
+// wildcardOriginRegexp converts the origin with wildcards to a regular expression.
+// A wildcard matches one or more characters of a host name or a port, but not "/" or ":".
+func wildcardOriginRegexp(origin string) *regexp.Regexp {
+	parts := strings.Split(origin, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile(`(?i)^` + strings.Join(parts, `[a-zA-Z0-9.-]+`) + `$`)
+}
+
+// addVary adds the value to the Vary header unless it is already listed.
+func addVary(header engine.Header, value string) {
+	for _, v := range header.Values(echo.HeaderVary) {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if item == "*" || strings.EqualFold(item, value) {
+				return
+			}
+		}
+	}
+	header.Add(echo.HeaderVary, value)
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+func TestCORS(t *testing.T) {
+	e := echo.New()
+	e.Use(CORS())
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+	client := test.NewClient(e)
+
+	client.Get(`/`).Header(echo.HeaderOrigin, `https://a.com`).Do().Expect(t).
+		Header(echo.HeaderAccessControlAllowOrigin, `*`).
+		NoHeader(echo.HeaderVary)
+	client.Get(`/`).Do().Expect(t).
+		NoHeader(echo.HeaderAccessControlAllowOrigin)
+}
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	e := echo.New()
+	e.Use(CORSWithConfig(CORSConfig{AllowOrigins: []string{`*`}, AllowCredentials: true}))
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+	client := test.NewClient(e)
+
+	// the credentials are not allowed for any origin by default
+	client.Get(`/`).Header(echo.HeaderOrigin, `https://evil.com`).Do().Expect(t).
+		Header(echo.HeaderAccessControlAllowOrigin, `*`).
+		NoHeader(echo.HeaderAccessControlAllowCredentials).
+		NoHeader(echo.HeaderVary)
+	client.Request(echo.OPTIONS, `/`).Header(echo.HeaderOrigin, `https://evil.com`).Header(echo.HeaderAccessControlRequestMethod, echo.POST).Do().Expect(t).
+		Header(echo.HeaderAccessControlAllowOrigin, `*`).
+		NoHeader(echo.HeaderAccessControlAllowCredentials)
+
+	e = echo.New()
+	e.Use(CORSWithConfig(CORSConfig{AllowOrigins: []string{`*`}, AllowCredentials: true, UnsafeWildcardOriginWithAllowCredentials: true}))
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+	test.NewClient(e).Get(`/`).Header(echo.HeaderOrigin, `https://a.com`).Do().Expect(t).
+		Header(echo.HeaderAccessControlAllowOrigin, `https://a.com`).
+		Header(echo.HeaderAccessControlAllowCredentials, `true`).
+		Header(echo.HeaderVary, echo.HeaderOrigin)
+}
+
+func TestCORSOriginPatterns(t *testing.T) {
+	e := echo.New()
+	e.Use(CORSWithConfig(CORSConfig{
+		AllowOrigins:        []string{`https://example.com`, `https://*.example.com`, `http://localhost:*`},
+		AllowOriginPatterns: []string{`^https://[a-z]+\.example\.org$`},
+		AllowOriginFunc: func(c echo.Context, origin string) (bool, error) {
+			if origin == `https://error.com` {
+				return false, errors.New(`tenant lookup failed`)
+			}
+			return origin == `https://tenant.com`, nil
+		},
+		AllowCredentials:    true,
+		AllowPrivateNetwork: true,
+		ExposeHeaders:       []string{`X-Total`},
+		MaxAge:              600,
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+	client := test.NewClient(e)
+
+	allowed := []string{
+		`https://example.com`,
+		`https://a.example.com`,
+		`https://a.b.example.com`,
+		`http://localhost:3000`,
+		`https://shop.example.org`,
+		`https://tenant.com`,
+	}
+	for _, origin := range allowed {
+		client.Get(`/`).Header(echo.HeaderOrigin, origin).Do().Expect(t).
+			Header(echo.HeaderAccessControlAllowOrigin, origin).
+			Header(echo.HeaderAccessControlAllowCredentials, `true`).
+			Header(echo.HeaderAccessControlExposeHeaders, `X-Total`).
+			Header(echo.HeaderVary, echo.HeaderOrigin)
+	}
+	denied := []string{
+		`https://evil.com`,
+		`https://example.com.evil.com`,
+		`https://evilexample.com`,
+		`http://a.example.com`,
+		`https://x.example.org.evil.com`,
+	}
+	for _, origin := range denied {
+		client.Get(`/`).Header(echo.HeaderOrigin, origin).Do().Expect(t).
+			Status(http.StatusOK).
+			NoHeader(echo.HeaderAccessControlAllowOrigin).
+			Header(echo.HeaderVary, echo.HeaderOrigin)
+	}
+	client.Get(`/`).Header(echo.HeaderOrigin, `https://error.com`).Do().Expect(t).
+		Status(http.StatusInternalServerError)
+
+	// Preflight
+	resp := client.Options(`/`).
+		Header(echo.HeaderOrigin, `https://a.example.com`).
+		Header(echo.HeaderAccessControlRequestMethod, echo.POST).
+		Header(echo.HeaderAccessControlRequestHeaders, `X-Token`).
+		Header(echo.HeaderAccessControlRequestPrivateNetwork, `true`).
+		Do()
+	resp.Expect(t).
+		Status(http.StatusNoContent).
+		Header(echo.HeaderAccessControlAllowOrigin, `https://a.example.com`).
+		Header(echo.HeaderAccessControlAllowHeaders, `X-Token`).
+		Header(echo.HeaderAccessControlAllowPrivateNetwork, `true`).
+		Header(echo.HeaderAccessControlMaxAge, `600`)
+	assert.Contains(t, resp.Header.Values(echo.HeaderVary), echo.HeaderAccessControlRequestPrivateNetwork)
+
+	client.Options(`/`).
+		Header(echo.HeaderOrigin, `https://evil.com`).
+		Header(echo.HeaderAccessControlRequestMethod, echo.POST).
+		Do().Expect(t).
+		Status(http.StatusNoContent).
+		NoHeader(echo.HeaderAccessControlAllowOrigin).
+		NoHeader(echo.HeaderAccessControlAllowMethods)
+}
@@ -0,0 +1,298 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/webx-top/echo"
+)
+
+// Sources of the Content Security Policy.
+const (
+	CSPSelf           = `'self'`
+	CSPNone           = `'none'`
+	CSPUnsafeInline   = `'unsafe-inline'`
+	CSPUnsafeEval     = `'unsafe-eval'`
+	CSPStrictDynamic  = `'strict-dynamic'`
+	CSPReportSample   = `'report-sample'`
+	CSPWasmUnsafeEval = `'wasm-unsafe-eval'`
+	CSPData           = `data:`
+	CSPBlob           = `blob:`
+	CSPHTTPS          = `https:`
+
+	// CSPNonceSource is replaced with `'nonce-<nonce>'` of every request.
+	CSPNonceSource = `'nonce-{nonce}'`
+
+	// CSPNonceKey is the key of the nonce in the context.
+	CSPNonceKey = `CSPNonce`
+)
+
+type cspDirective struct {
+	name   string
+	values []string
+}
+
+// CSP builds a Content Security Policy.
+//
+//	csp := middleware.NewCSP().
+//		DefaultSrc(middleware.CSPSelf).
+//		ScriptSrc(middleware.CSPNonceSource, middleware.CSPStrictDynamic).
+//		ObjectSrc(middleware.CSPNone).
+//		ReportTo(`csp`)
+type CSP struct {
+	directives []*cspDirective
+}
+
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Directive adds the sources to the directive. The directives keep the order they are added.
+func (p *CSP) Directive(name string, values ...string) *CSP {
+	for _, d := range p.directives {
+		if d.name == name {
+			d.values = append(d.values, values...)
+			return p
+		}
+	}
+	p.directives = append(p.directives, &cspDirective{name: name, values: values})
+	return p
+}
+
+// Remove removes the directive.
+func (p *CSP) Remove(name string) *CSP {
+	for i, d := range p.directives {
+		if d.name == name {
+			p.directives = append(p.directives[:i], p.directives[i+1:]...)
+			break
+		}
+	}
+	return p
+}
+
+func (p *CSP) DefaultSrc(sources ...string) *CSP {
+	return p.Directive(`default-src`, sources...)
+}
+
+func (p *CSP) ScriptSrc(sources ...string) *CSP {
+	return p.Directive(`script-src`, sources...)
+}
+
+func (p *CSP) StyleSrc(sources ...string) *CSP {
+	return p.Directive(`style-src`, sources...)
+}
+
+func (p *CSP) ImgSrc(sources ...string) *CSP {
+	return p.Directive(`img-src`, sources...)
+}
+
+func (p *CSP) ConnectSrc(sources ...string) *CSP {
+	return p.Directive(`connect-src`, sources...)
+}
+
+func (p *CSP) FontSrc(sources ...string) *CSP {
+	return p.Directive(`font-src`, sources...)
+}
+
+func (p *CSP) ObjectSrc(sources ...string) *CSP {
+	return p.Directive(`object-src`, sources...)
+}
+
+func (p *CSP) MediaSrc(sources ...string) *CSP {
+	return p.Directive(`media-src`, sources...)
+}
+
+func (p *CSP) FrameSrc(sources ...string) *CSP {
+	return p.Directive(`frame-src`, sources...)
+}
+
+func (p *CSP) WorkerSrc(sources ...string) *CSP {
+	return p.Directive(`worker-src`, sources...)
+}
+
+func (p *CSP) FrameAncestors(sources ...string) *CSP {
+	return p.Directive(`frame-ancestors`, sources...)
+}
+
+func (p *CSP) BaseURI(sources ...string) *CSP {
+	return p.Directive(`base-uri`, sources...)
+}
+
+func (p *CSP) FormAction(sources ...string) *CSP {
+	return p.Directive(`form-action`, sources...)
+}
+
+func (p *CSP) UpgradeInsecureRequests() *CSP {
+	return p.Directive(`upgrade-insecure-requests`)
+}
+
+// ReportURI sets the deprecated `report-uri` directive, which is still needed by the browsers
+// without the Reporting API.
+func (p *CSP) ReportURI(uri string) *CSP {
+	return p.Remove(`report-uri`).Directive(`report-uri`, uri)
+}
+
+// ReportTo sets the `report-to` directive to the group defined in `SecureConfig.ReportingEndpoints`.
+func (p *CSP) ReportTo(group string) *CSP {
+	return p.Remove(`report-to`).Directive(`report-to`, group)
+}
+
+// HasNonce reports whether the policy contains `CSPNonceSource`.
+func (p *CSP) HasNonce() bool {
+	return strings.Contains(p.String(), CSPNonceSource)
+}
+
+// String returns the policy. `CSPNonceSource` is not replaced.
+func (p *CSP) String() string {
+	parts := make([]string, 0, len(p.directives))
+	for _, d := range p.directives {
+		if len(d.values) == 0 {
+			parts = append(parts, d.name)
+			continue
+		}
+		parts = append(parts, d.name+` `+strings.Join(d.values, ` `))
+	}
+	return strings.Join(parts, `; `)
+}
+
+// Build returns the policy with the nonce.
+func (p *CSP) Build(nonce string) string {
+	return WithCSPNonce(p.String(), nonce)
+}
+
+// WithCSPNonce replaces `CSPNonceSource` in the policy with the nonce.
+func WithCSPNonce(policy string, nonce string) string {
+	return strings.ReplaceAll(policy, CSPNonceSource, `'nonce-`+nonce+`'`)
+}
+
+// NewCSPNonce returns a random nonce of 128 bits.
+func NewCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// CSPNonce returns the nonce of the request set by the Secure middleware.
+// It is also available in the templates as `{{CSPNonce}}`.
+func CSPNonce(c echo.Context) string {
+	nonce, _ := c.Internal().Get(CSPNonceKey).(string)
+	return nonce
+}
+
+// CSPReport is a violation report of the Content Security Policy.
+type CSPReport struct {
+	DocumentURL        string `json:"documentURL"`
+	Referrer           string `json:"referrer,omitempty"`
+	BlockedURL         string `json:"blockedURL,omitempty"`
+	EffectiveDirective string `json:"effectiveDirective"`
+	OriginalPolicy     string `json:"originalPolicy"`
+	Disposition        string `json:"disposition"` // "enforce" or "report"
+	SourceFile         string `json:"sourceFile,omitempty"`
+	LineNumber         int    `json:"lineNumber,omitempty"`
+	ColumnNumber       int    `json:"columnNumber,omitempty"`
+	StatusCode         int    `json:"statusCode,omitempty"`
+	Sample             string `json:"sample,omitempty"`
+	UserAgent          string `json:"-"`
+}
+
+// cspLegacyReport is the body of the reports sent to `report-uri`.
+type cspLegacyReport struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	BlockedURI         string `json:"blocked-uri"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+	ScriptSample       string `json:"script-sample"`
+}
+
+// ParseCSPReports parses the body of the reports sent to `report-uri` (`application/csp-report`)
+// or by the Reporting API (`application/reports+json`). The other types of reports are ignored.
+func ParseCSPReports(body []byte) ([]*CSPReport, error) {
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) > 0 && body[0] == '[' {
+		var reports []struct {
+			Type      string    `json:"type"`
+			UserAgent string    `json:"user_agent"`
+			Body      CSPReport `json:"body"`
+		}
+		if err := json.Unmarshal(body, &reports); err != nil {
+			return nil, err
+		}
+		result := make([]*CSPReport, 0, len(reports))
+		for _, r := range reports {
+			if r.Type != `csp-violation` {
+				continue
+			}
+			report := r.Body
+			report.UserAgent = r.UserAgent
+			result = append(result, &report)
+		}
+		return result, nil
+	}
+	var legacy struct {
+		Report cspLegacyReport `json:"csp-report"`
+	}
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, err
+	}
+	r := legacy.Report
+	directive := r.EffectiveDirective
+	if len(directive) == 0 {
+		directive = strings.SplitN(r.ViolatedDirective, ` `, 2)[0]
+	}
+	return []*CSPReport{{
+		DocumentURL:        r.DocumentURI,
+		Referrer:           r.Referrer,
+		BlockedURL:         r.BlockedURI,
+		EffectiveDirective: directive,
+		OriginalPolicy:     r.OriginalPolicy,
+		Disposition:        r.Disposition,
+		SourceFile:         r.SourceFile,
+		LineNumber:         r.LineNumber,
+		ColumnNumber:       r.ColumnNumber,
+		StatusCode:         r.StatusCode,
+		Sample:             r.ScriptSample,
+	}}, nil
+}
+
+// CSPReportMaxSize is the maximum size of the body accepted by `CSPReportHandler`.
+var CSPReportMaxSize int64 = 64 << 10
+
+// CSPReportHandler returns a handler receiving the violation reports, for both the `report-uri`
+// directive and the Reporting API, e.g. `e.Post("/csp-report", middleware.CSPReportHandler(fn))`.
+func CSPReportHandler(fn func(echo.Context, []*CSPReport) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(io.LimitReader(c.Request().Body(), CSPReportMaxSize))
+		if err != nil {
+			return err
+		}
+		reports, err := ParseCSPReports(body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetRaw(err)
+		}
+		userAgent := c.Request().UserAgent()
+		for _, report := range reports {
+			if len(report.UserAgent) == 0 {
+				report.UserAgent = userAgent
+			}
+		}
+		if len(reports) > 0 {
+			if err = fn(c, reports); err != nil {
+				return err
+			}
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
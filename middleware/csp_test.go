@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+func TestCSPBuilder(t *testing.T) {
+	csp := NewCSP().
+		DefaultSrc(CSPSelf).
+		ScriptSrc(CSPNonceSource, CSPStrictDynamic).
+		ObjectSrc(CSPNone).
+		UpgradeInsecureRequests().
+		ReportURI(`/csp-report`).
+		ReportTo(`csp`)
+	csp.ScriptSrc(CSPHTTPS)
+	assert.True(t, csp.HasNonce())
+	assert.Equal(t, `default-src 'self'; script-src 'nonce-{nonce}' 'strict-dynamic' https:; object-src 'none'; upgrade-insecure-requests; report-uri /csp-report; report-to csp`, csp.String())
+	assert.Equal(t, `script-src 'nonce-abc'`, NewCSP().ScriptSrc(CSPNonceSource).Build(`abc`))
+	assert.NotEqual(t, NewCSPNonce(), NewCSPNonce())
+}
+
+func TestSecureCSPNonce(t *testing.T) {
+	e := echo.New()
+	e.Use(SecureWithConfig(SecureConfig{
+		CSP:                     NewCSP().ScriptSrc(CSPNonceSource),
+		CSPReportOnly:           true,
+		ReportingEndpoints:      `csp="https://example.com/csp"`,
+		PermissionsPolicy:       `camera=()`,
+		CrossOriginOpenerPolicy: `same-origin`,
+		ReferrerPolicy:          `no-referrer`,
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		fn := c.GetFunc(`CSPNonce`).(func() string)
+		assert.Equal(t, CSPNonce(c), fn())
+		return c.String(CSPNonce(c))
+	})
+	e.Commit()
+
+	client := test.NewClient(e)
+	resp := client.Get(`/`).Do()
+	nonce := resp.String()
+	assert.NotEmpty(t, nonce)
+	resp.Expect(t).
+		Header(echo.HeaderContentSecurityPolicyReportOnly, `script-src 'nonce-`+nonce+`'`).
+		NoHeader(echo.HeaderContentSecurityPolicy).
+		Header(echo.HeaderReportingEndpoints, `csp="https://example.com/csp"`).
+		Header(echo.HeaderPermissionsPolicy, `camera=()`).
+		Header(echo.HeaderCrossOriginOpenerPolicy, `same-origin`).
+		Header(echo.HeaderReferrerPolicy, `no-referrer`).
+		NoHeader(echo.HeaderCrossOriginEmbedderPolicy)
+	assert.NotEqual(t, nonce, client.Get(`/`).Do().String())
+}
+
+func TestCSPReportHandler(t *testing.T) {
+	var received []*CSPReport
+	e := echo.New()
+	e.Post(`/csp-report`, CSPReportHandler(func(c echo.Context, reports []*CSPReport) error {
+		received = append(received, reports...)
+		return nil
+	}))
+	e.Commit()
+	client := test.NewClient(e)
+
+	legacy := `{"csp-report":{"document-uri":"https://example.com/","blocked-uri":"inline","violated-directive":"script-src-elem 'self'","original-policy":"script-src 'self'","disposition":"enforce","line-number":3}}`
+	client.Post(`/csp-report`).Body(strings.NewReader(legacy), `application/csp-report`).Header(`User-Agent`, `UA1`).Do().
+		Expect(t).Status(http.StatusNoContent)
+	reportsAPI := `[{"type":"csp-violation","user_agent":"UA2","body":{"documentURL":"https://example.com/a","blockedURL":"https://evil.com/x.js","effectiveDirective":"script-src-elem","disposition":"report"}},{"type":"deprecation","body":{}}]`
+	client.Post(`/csp-report`).Body(bytes.NewBufferString(reportsAPI), `application/reports+json`).Do().
+		Expect(t).Status(http.StatusNoContent)
+	client.Post(`/csp-report`).Body(strings.NewReader(`{`), `application/csp-report`).Do().
+		Expect(t).Status(http.StatusBadRequest)
+
+	assert.Len(t, received, 2)
+	assert.Equal(t, `https://example.com/`, received[0].DocumentURL)
+	assert.Equal(t, `script-src-elem`, received[0].EffectiveDirective)
+	assert.Equal(t, 3, received[0].LineNumber)
+	assert.Equal(t, `UA1`, received[0].UserAgent)
+	assert.Equal(t, `https://evil.com/x.js`, received[1].BlockedURL)
+	assert.Equal(t, `report`, received[1].Disposition)
+	assert.Equal(t, `UA2`, received[1].UserAgent)
+}
@@ -1,10 +1,17 @@
 package middleware
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/webx-top/echo"
@@ -30,13 +37,64 @@ type (
 		// - "query:<name>"
 		TokenLookup string `json:"token_lookup"`
 
-		// Context key to store generated CSRF token into context.
+		// Context key to store the masked CSRF token into context. The value is the
+		// token XORed with a one-time pad, which is different in every response, and
+		// is accepted by the middleware in place of the raw token.
 		// Optional. Default value "csrf".
 		ContextKey string `json:"context_key"`
 
 		// Name of the CSRF session. This session will store CSRF token.
 		// Optional. Default value "_csrf".
 		SessionName string `json:"session_name"`
+
+		// Mode defines how the requests are verified.
+		// Optional. Default value CSRFModeSession.
+		// Possible values:
+		// - CSRFModeSession: the token is stored in the session
+		// - CSRFModeCookie: the token is stored in a signed cookie (double-submit cookie),
+		//   no session is needed
+		// - CSRFModeOrigin: no token, the `Sec-Fetch-Site`, `Origin` or `Referer` header
+		//   of the request is verified
+		Mode string `json:"mode"`
+
+		// Secret is the key to sign the token cookie in CSRFModeCookie.
+		// Optional. A random secret is generated if empty, the tokens are invalid
+		// after restarting the server or on other instances in this case.
+		Secret []byte `json:"-"`
+
+		// SessionID returns the value binding the token cookie to the session of the user
+		// in CSRFModeCookie, which is signed with the token (signed double-submit cookie),
+		// so that a cookie injected by a sibling subdomain is invalid for the other users.
+		// It must not change during the session, e.g. the ID of the authenticated user or
+		// `c.Session().ID()` of a server-side session store. It should not create a
+		// session, which CSRFModeCookie does not need.
+		// Optional. The token cookie is not bound to a session by default.
+		SessionID func(echo.Context) string `json:"-"`
+
+		// Name of the token cookie in CSRFModeCookie.
+		// Optional. Default value "_csrf".
+		CookieName string `json:"cookie_name"`
+
+		// CookieOptions of the token cookie in CSRFModeCookie. The cookie is readable
+		// by JavaScript unless `HttpOnly` is true, so that it can be sent in the header.
+		// Optional. Default value `DefaultCSRFCookieOptions`.
+		CookieOptions *echo.CookieOptions `json:"cookie_options"`
+
+		// TrustedOrigins defines the other origins allowed to send the requests in
+		// CSRFModeOrigin, e.g. "https://admin.example.com".
+		// Optional.
+		TrustedOrigins []string `json:"trusted_origins"`
+
+		// AllowSameSite allows the requests from the same site (`Sec-Fetch-Site: same-site`),
+		// e.g. from the other subdomains, in CSRFModeOrigin.
+		// Optional. Default value false.
+		AllowSameSite bool `json:"allow_same_site"`
+
+		// AllowMissingOrigin allows the requests without any of the `Sec-Fetch-Site`,
+		// `Origin` and `Referer` headers in CSRFModeOrigin, which are usually not sent
+		// by a browser.
+		// Optional. Default value false.
+		AllowMissingOrigin bool `json:"allow_missing_origin"`
 	}
 
 	// csrfTokenExtractor defines a function that takes `echo.Context` and returns
@@ -44,6 +102,13 @@ type (
 	csrfTokenExtractor func(echo.Context) (string, error)
 )
 
+// Modes of the CSRF middleware.
+const (
+	CSRFModeSession = "session"
+	CSRFModeCookie  = "cookie"
+	CSRFModeOrigin  = "origin"
+)
+
 var (
 	// DefaultCSRFConfig is the default CSRF middleware config.
 	DefaultCSRFConfig = CSRFConfig{
@@ -52,11 +117,21 @@ var (
 		TokenLookup: "header:" + echo.HeaderXCSRFToken,
 		ContextKey:  "csrf",
 		SessionName: "_csrf",
+		Mode:        CSRFModeSession,
+		CookieName:  "_csrf",
+	}
+	// DefaultCSRFCookieOptions is the default options of the token cookie in CSRFModeCookie.
+	DefaultCSRFCookieOptions = &echo.CookieOptions{
+		Path:     "/",
+		MaxAge:   86400,
+		SameSite: "lax",
 	}
 	ErrCSRFTokenInvalid        = errors.New("csrf token is invalid")
 	ErrCSRFTokenIsEmpty        = errors.New("empty csrf token")
 	ErrCSRFTokenIsEmptyInForm  = fmt.Errorf("%w in form param", ErrCSRFTokenIsEmpty)
 	ErrCSRFTokenIsEmptyInQuery = fmt.Errorf("%w in query param", ErrCSRFTokenIsEmpty)
+	ErrCSRFOriginInvalid       = errors.New("cross-site request is forbidden")
+	ErrCSRFOriginMissing       = errors.New("missing origin and referer header")
 )
 
 // CSRF returns a Cross-Site Request Forgery (CSRF) middleware.
@@ -84,6 +159,21 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFuncd {
 	if config.SessionName == "" {
 		config.SessionName = DefaultCSRFConfig.SessionName
 	}
+	if config.Mode == "" {
+		config.Mode = DefaultCSRFConfig.Mode
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultCSRFConfig.CookieName
+	}
+	if config.CookieOptions == nil {
+		config.CookieOptions = DefaultCSRFCookieOptions
+	}
+	if config.Mode == CSRFModeCookie && len(config.Secret) == 0 {
+		config.Secret = make([]byte, 32)
+		if _, err := rand.Read(config.Secret); err != nil {
+			panic(err)
+		}
+	}
 	// Initialize
 	parts := strings.SplitN(config.TokenLookup, ":", 2)
 	extractor := csrfTokenFromHeader(parts[1])
@@ -95,6 +185,11 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFuncd {
 	case "any":
 		extractor = csrfTokenFromAny(parts[1])
 	}
+	fieldName := parts[1]
+	trustedOrigins := make(map[string]struct{}, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		trustedOrigins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = struct{}{}
+	}
 
 	return func(next echo.Handler) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -102,30 +197,55 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFuncd {
 				return next.Handle(c)
 			}
 			req := c.Request()
-			token, _ := c.Session().Get(config.SessionName).(string)
+			if config.Mode == CSRFModeOrigin {
+				if !isSafeMethod(req.Method()) {
+					if err := verifyCSRFOrigin(c, &config, trustedOrigins); err != nil {
+						return echo.NewHTTPError(http.StatusForbidden, err.Error()).SetRaw(err)
+					}
+				}
+				return next.Handle(c)
+			}
+
+			var token, sessionID string
+			if config.Mode == CSRFModeCookie {
+				if config.SessionID != nil {
+					sessionID = config.SessionID(c)
+				}
+				token = unsignCSRFToken(c.GetCookie(config.CookieName), sessionID, config.Secret)
+			} else {
+				token, _ = c.Session().Get(config.SessionName).(string)
+			}
 			if len(token) == 0 {
 				// Generate token
 				token = random.String(config.TokenLength)
 			}
 
-			switch req.Method() {
-			case echo.GET, echo.HEAD, echo.OPTIONS, echo.TRACE:
-			default:
+			if !isSafeMethod(req.Method()) {
 				// Validate token only for requests which are not defined as 'safe' by RFC7231
 				clientToken, err := extractor(c)
 				if err != nil {
 					return err
 				}
-				if !validateCSRFToken(token, clientToken) {
+				if !matchCSRFToken(token, clientToken, sessionID, config.Secret) {
 					return echo.NewHTTPError(http.StatusForbidden, ErrCSRFTokenInvalid.Error()).SetRaw(ErrCSRFTokenInvalid)
 				}
 			}
 
 			// Store CSRF
-			c.Session().Set(config.SessionName, token)
+			if config.Mode == CSRFModeCookie {
+				c.SetCookie(config.CookieName, signCSRFToken(token, sessionID, config.Secret), config.CookieOptions)
+			} else {
+				c.Session().Set(config.SessionName, token)
+			}
 
-			// Store token in the context
-			c.Internal().Set(config.ContextKey, token)
+			// Store the masked token in the context, which is different in every response
+			// to mitigate BREACH attacks.
+			masked := maskCSRFToken(token)
+			c.Internal().Set(config.ContextKey, masked)
+			c.SetFunc(`CSRFToken`, func() string { return masked })
+			c.SetFunc(`CSRFField`, func() template.HTML {
+				return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(fieldName) + `" value="` + masked + `">`)
+			})
 
 			// Protect clients from caching the response
 			c.Response().Header().Add(echo.HeaderVary, echo.HeaderCookie)
@@ -135,6 +255,116 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFuncd {
 	}
 }
 
+func isSafeMethod(method string) bool {
+	switch method {
+	case echo.GET, echo.HEAD, echo.OPTIONS, echo.TRACE:
+		return true
+	}
+	return false
+}
+
+// verifyCSRFOrigin verifies the request is sent from the same origin by the
+// `Sec-Fetch-Site` header, or by the `Origin` or `Referer` header for the
+// browsers which do not support Fetch Metadata.
+func verifyCSRFOrigin(c echo.Context, config *CSRFConfig, trustedOrigins map[string]struct{}) error {
+	header := c.Request().Header()
+	origin := header.Get(echo.HeaderOrigin)
+	switch header.Get(echo.HeaderSecFetchSite) {
+	case "same-origin", "none": // "none" is a user-initiated request, e.g. a bookmark
+		return nil
+	case "same-site":
+		if config.AllowSameSite {
+			return nil
+		}
+		fallthrough
+	case "cross-site":
+		if _, ok := trustedOrigins[strings.ToLower(origin)]; ok {
+			return nil
+		}
+		return ErrCSRFOriginInvalid
+	}
+	if len(origin) == 0 || origin == "null" {
+		referer := header.Get(echo.HeaderReferer)
+		if len(referer) == 0 {
+			if len(origin) == 0 && config.AllowMissingOrigin {
+				return nil
+			}
+			return ErrCSRFOriginMissing
+		}
+		u, err := url.Parse(referer)
+		if err != nil || len(u.Host) == 0 {
+			return ErrCSRFOriginInvalid
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+	origin = strings.ToLower(origin)
+	if origin == strings.ToLower(c.Scheme()+"://"+c.Request().Host()) {
+		return nil
+	}
+	if _, ok := trustedOrigins[origin]; ok {
+		return nil
+	}
+	return ErrCSRFOriginInvalid
+}
+
+// signCSRFToken returns the token with the signature, which is the value of the token cookie.
+func signCSRFToken(token string, sessionID string, secret []byte) string {
+	return token + "." + csrfTokenSignature(token, sessionID, secret)
+}
+
+// unsignCSRFToken returns the token of the cookie value, or an empty string if the
+// signature is invalid.
+func unsignCSRFToken(value string, sessionID string, secret []byte) string {
+	pos := strings.LastIndexByte(value, '.')
+	if pos < 1 {
+		return ""
+	}
+	token := value[:pos]
+	if !hmac.Equal([]byte(value[pos+1:]), []byte(csrfTokenSignature(token, sessionID, secret))) {
+		return ""
+	}
+	return token
+}
+
+// csrfTokenSignature returns the HMAC of the session ID and the token, which are
+// prefixed with the length of the session ID to be unambiguous.
+func csrfTokenSignature(token string, sessionID string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.Itoa(len(sessionID)) + "!" + sessionID + "!" + token))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// maskCSRFToken returns a one-time pad and the token XORed with it.
+func maskCSRFToken(token string) string {
+	size := len(token)
+	b := make([]byte, size*2)
+	if _, err := rand.Read(b[:size]); err != nil {
+		panic(err)
+	}
+	for i := 0; i < size; i++ {
+		b[size+i] = b[i] ^ token[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// matchCSRFToken reports whether the token sent by the client matches the token.
+// The client can send the masked token, the raw token or the value of the token cookie.
+func matchCSRFToken(token, clientToken string, sessionID string, secret []byte) bool {
+	size := len(token)
+	if len(clientToken) == base64.RawURLEncoding.EncodedLen(size*2) {
+		if b, err := base64.RawURLEncoding.DecodeString(clientToken); err == nil {
+			for i := 0; i < size; i++ {
+				b[i] ^= b[size+i]
+			}
+			return validateCSRFToken(token, string(b[:size]))
+		}
+	}
+	if len(secret) > 0 && strings.Contains(clientToken, ".") {
+		clientToken = unsignCSRFToken(clientToken, sessionID, secret)
+	}
+	return validateCSRFToken(token, clientToken)
+}
+
 // csrfTokenFromForm returns a `csrfTokenExtractor` that extracts token from the
 // provided request header.
 func csrfTokenFromHeader(header string) csrfTokenExtractor {
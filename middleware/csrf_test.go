@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware/session"
+	test "github.com/webx-top/echo/testing"
+)
+
+func TestCSRFCookieMode(t *testing.T) {
+	e := echo.New()
+	e.Use(CSRFWithConfig(CSRFConfig{
+		Mode:        CSRFModeCookie,
+		Secret:      []byte(`secret`),
+		TokenLookup: `any:csrf`,
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		field := c.GetFunc(`CSRFField`).(func() template.HTML)()
+		assert.Contains(t, string(field), `<input type="hidden" name="csrf" value="`+c.Internal().String(`csrf`)+`">`)
+		return c.String(c.Internal().String(`csrf`))
+	})
+	e.Post(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+	client := test.NewClient(e)
+
+	client.Post(`/`).Do().Expect(t).Status(http.StatusForbidden)
+
+	masked := client.Get(`/`).Do().String()
+	cookie := client.Cookie(`_csrf`)
+	assert.NotEmpty(t, cookie)
+	assert.NotEqual(t, masked, client.Get(`/`).Do().String())
+
+	client.Post(`/`).FormValue(`csrf`, masked).Do().Expect(t).Status(http.StatusOK).Body(`OK`)
+	client.Post(`/`).Header(`csrf`, cookie).Do().Expect(t).Status(http.StatusOK)
+	client.Post(`/`).Header(`csrf`, `invalid`).Do().Expect(t).Status(http.StatusForbidden)
+
+	// forged cookie
+	other := test.NewClient(e)
+	other.Post(`/`).Cookie(&http.Cookie{Name: `_csrf`, Value: `abc.def`}).Header(`csrf`, `abc.def`).Do().Expect(t).Status(http.StatusForbidden)
+	other.Post(`/`).Cookie(&http.Cookie{Name: `_csrf`, Value: `abc.def`}).Header(`csrf`, `abc`).Do().Expect(t).Status(http.StatusForbidden)
+}
+
+func TestCSRFCookieModeSessionBound(t *testing.T) {
+	e := echo.New()
+	e.Use(CSRFWithConfig(CSRFConfig{
+		Mode:        CSRFModeCookie,
+		Secret:      []byte(`secret`),
+		TokenLookup: `header:X-CSRF-Token`,
+		SessionID:   func(c echo.Context) string { return c.GetCookie(`sid`) },
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(c.Internal().String(`csrf`))
+	})
+	e.Post(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+
+	victim := test.NewClient(e)
+	victim.Get(`/`).Cookie(&http.Cookie{Name: `sid`, Value: `victim`}).Do()
+	cookie := victim.Cookie(`_csrf`)
+	assert.NotEmpty(t, cookie)
+	victim.Post(`/`).Cookie(&http.Cookie{Name: `sid`, Value: `victim`}).Header(echo.HeaderXCSRFToken, cookie).Do().Expect(t).Status(http.StatusOK)
+
+	// the token cookie of an attacker is invalid in the session of the victim
+	attacker := test.NewClient(e)
+	attacker.Get(`/`).Cookie(&http.Cookie{Name: `sid`, Value: `attacker`}).Do()
+	forged := attacker.Cookie(`_csrf`)
+	assert.NotEmpty(t, forged)
+	test.NewClient(e).Post(`/`).
+		Cookie(&http.Cookie{Name: `sid`, Value: `victim`}).
+		Cookie(&http.Cookie{Name: `_csrf`, Value: forged}).
+		Header(echo.HeaderXCSRFToken, forged).
+		Do().Expect(t).Status(http.StatusForbidden)
+}
+
+func TestCSRFCookieModeWithoutSession(t *testing.T) {
+	e := echo.New()
+	e.Use(session.Middleware(nil), CSRFWithConfig(CSRFConfig{
+		Mode:   CSRFModeCookie,
+		Secret: []byte(`secret`),
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		return c.String(c.Internal().String(`csrf`))
+	})
+	e.Commit()
+
+	resp := test.NewClient(e).Get(`/`).Do()
+	resp.Expect(t).Status(http.StatusOK)
+	cookies := resp.Header.Values(echo.HeaderSetCookie)
+	if assert.Len(t, cookies, 1) {
+		assert.True(t, strings.HasPrefix(cookies[0], `_csrf=`))
+	}
+}
+
+func TestCSRFOriginMode(t *testing.T) {
+	e := echo.New()
+	e.Use(CSRFWithConfig(CSRFConfig{
+		Mode:           CSRFModeOrigin,
+		TrustedOrigins: []string{`https://admin.example.com`},
+	}))
+	e.Post(`/`, func(c echo.Context) error {
+		return c.String(`OK`)
+	})
+	e.Commit()
+	client := test.NewClient(e)
+	client.Host = `example.com`
+
+	cases := []struct {
+		headers map[string]string
+		status  int
+	}{
+		{map[string]string{echo.HeaderSecFetchSite: `same-origin`}, http.StatusOK},
+		{map[string]string{echo.HeaderSecFetchSite: `none`}, http.StatusOK},
+		{map[string]string{echo.HeaderSecFetchSite: `cross-site`, echo.HeaderOrigin: `https://evil.com`}, http.StatusForbidden},
+		{map[string]string{echo.HeaderSecFetchSite: `same-site`, echo.HeaderOrigin: `https://a.example.com`}, http.StatusForbidden},
+		{map[string]string{echo.HeaderSecFetchSite: `same-site`, echo.HeaderOrigin: `https://admin.example.com`}, http.StatusOK},
+		{map[string]string{echo.HeaderOrigin: `http://example.com`}, http.StatusOK},
+		{map[string]string{echo.HeaderOrigin: `http://evil.com`}, http.StatusForbidden},
+		{map[string]string{echo.HeaderOrigin: `null`, echo.HeaderReferer: `http://example.com/form`}, http.StatusOK},
+		{map[string]string{echo.HeaderReferer: `http://evil.com/form`}, http.StatusForbidden},
+		{map[string]string{}, http.StatusForbidden},
+	}
+	for _, v := range cases {
+		req := client.Post(`/`)
+		for k, h := range v.headers {
+			req.Header(k, h)
+		}
+		assert.Equal(t, v.status, req.Do().Code, v.headers)
+	}
+	client.Get(`/`).Header(echo.HeaderOrigin, `http://evil.com`).Do().Expect(t).Status(http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,196 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/admpub/confl"
+
+	"github.com/webx-top/echo/middleware/language"
+)
+
+// Messages 某个语种的语言包内容
+type Messages map[string]string
+
+// LoadMessages 读取语言包。兼容 i18n 的两种目录结构：<dir>/<lang>.yaml 和 <dir>/<lang>/*.yaml
+func LoadMessages(lang string, messagesPaths ...string) (Messages, error) {
+	files, err := loadMessageFiles(lang, messagesPaths...)
+	messages := Messages{}
+	for _, file := range files {
+		for key, value := range file.rows {
+			messages[key] = value
+		}
+	}
+	return messages, err
+}
+
+type messageFile struct {
+	path    string
+	rows    map[string]string
+	changed bool
+}
+
+// loadMessageFiles 按 LoadMessages 的顺序读取某个语种已存在的语言包文件(后面的文件覆盖前面的)
+func loadMessageFiles(lang string, messagesPaths ...string) ([]*messageFile, error) {
+	var result []*messageFile
+	for _, dir := range messagesPaths {
+		files, err := filepath.Glob(filepath.Join(dir, lang, `*.yaml`))
+		if err != nil {
+			return result, err
+		}
+		files = append([]string{filepath.Join(dir, lang+`.yaml`)}, files...)
+		for _, file := range files {
+			rows := map[string]string{}
+			_, err := confl.DecodeFile(file, &rows)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return result, err
+			}
+			result = append(result, &messageFile{path: file, rows: rows})
+		}
+	}
+	return result, nil
+}
+
+// Report 某个语种的比对结果
+type Report struct {
+	Lang    string
+	Missing []string // 源码中使用了但语言包中没有的文本
+	Unused  []string // 语言包中有但源码中未使用的文本
+}
+
+// OK 没有缺失和多余的文本时返回 true
+func (r *Report) OK() bool {
+	return len(r.Missing) == 0 && len(r.Unused) == 0
+}
+
+// Diff 比对提取到的文本和语言包。译文为空的文本视为缺失
+func Diff(lang string, keys Keys, messages Messages) *Report {
+	r := &Report{Lang: lang}
+	for key := range keys {
+		if len(messages[key]) == 0 {
+			r.Missing = append(r.Missing, key)
+		}
+	}
+	for key := range messages {
+		if _, ok := keys[key]; !ok {
+			r.Unused = append(r.Unused, key)
+		}
+	}
+	sort.Strings(r.Missing)
+	sort.Strings(r.Unused)
+	return r
+}
+
+// SyncOptions 同步选项
+type SyncOptions struct {
+	Write bool // 是否将缺失的文本写入语言包文件
+	Prune bool // 是否从语言包文件中删除未使用的文本
+}
+
+// Sync 比对 config.AllList 中的每个语种并按选项更新定义了这些文本的语言包文件。
+// 新增的文本写入该语种的第一个语言包文件(没有时为 <MessagesPath[0]>/<lang>.yaml)，
+// 默认语种以原文作为译文，其它语种的译文留空，留空的文本在翻译前仍会被报告为缺失
+func Sync(config *language.Config, keys Keys, opts SyncOptions) ([]*Report, error) {
+	langs := config.AllList
+	if len(langs) == 0 && len(config.Default) > 0 {
+		langs = []string{config.Default}
+	}
+	reports := make([]*Report, 0, len(langs))
+	for _, lang := range langs {
+		files, err := loadMessageFiles(lang, config.MessagesPath...)
+		if err != nil {
+			return reports, err
+		}
+		messages := Messages{}
+		for _, file := range files {
+			for key, value := range file.rows {
+				messages[key] = value
+			}
+		}
+		report := Diff(lang, keys, messages)
+		reports = append(reports, report)
+		if !opts.Write || len(config.MessagesPath) == 0 {
+			continue
+		}
+		if len(files) == 0 {
+			files = []*messageFile{{path: filepath.Join(config.MessagesPath[0], lang+`.yaml`), rows: map[string]string{}}}
+		}
+		for _, key := range report.Missing {
+			if _, ok := messages[key]; ok { // 已有但未翻译
+				continue
+			}
+			var value string
+			if lang == config.Default {
+				value = key
+			}
+			files[0].rows[key] = value
+			files[0].changed = true
+		}
+		if opts.Prune {
+			for _, key := range report.Unused {
+				for _, file := range files {
+					if _, ok := file.rows[key]; ok {
+						delete(file.rows, key)
+						file.changed = true
+					}
+				}
+			}
+		}
+		for _, file := range files {
+			if !file.changed {
+				continue
+			}
+			if err := WriteMessages(file.path, file.rows); err != nil {
+				return reports, err
+			}
+		}
+	}
+	return reports, nil
+}
+
+// WriteMessages 将文本写入语言包文件
+func WriteMessages(file string, rows map[string]string) error {
+	b, err := confl.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+// LangsOf 解析以逗号分隔的语种列表
+func LangsOf(list string) []string {
+	var langs []string
+	for _, lang := range strings.Split(list, `,`) {
+		lang = strings.TrimSpace(lang)
+		if len(lang) > 0 {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
@@ -0,0 +1,63 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo/middleware/language"
+)
+
+func TestExtractContent(t *testing.T) {
+	keys := Keys{}
+	e := NewExtractor()
+	e.ExtractContent(keys, `a.go`, []byte("c.T(\"Hello\")\nreturn c.E(`Not found: %s`, id)\nc.T(\"Say \\\"hi\\\"\")"), GoRegexes)
+	e.ExtractContent(keys, `a.html`, []byte(`{{T "Title"}} {{$.T "Page %d" 1}} {{printf "%s" (T "Nested")}} {{"Piped"|T}} {{"Raw"|ToHTML}}`), TemplateRegexes)
+	assert.Equal(t, []string{`Hello`, `Nested`, `Not found: %s`, `Page %d`, `Piped`, `Say "hi"`, `Title`}, keys.Sorted())
+	assert.Equal(t, []Location{{File: `a.go`, Line: 2}}, keys[`Not found: %s`])
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, WriteMessages(filepath.Join(dir, `en.yaml`), map[string]string{`Hello`: `Hello`, `Old`: `Old`}))
+	assert.NoError(t, WriteMessages(filepath.Join(dir, `zh-cn`, `app.yaml`), map[string]string{`Hello`: `你好`, `Old`: `旧`}))
+	keys := Keys{`Hello`: nil, `World`: nil}
+	config := &language.Config{Default: `en`, AllList: []string{`en`, `zh-cn`, `fr`}, MessagesPath: []string{dir}}
+
+	reports, err := Sync(config, keys, SyncOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Report{
+		{Lang: `en`, Missing: []string{`World`}, Unused: []string{`Old`}},
+		{Lang: `zh-cn`, Missing: []string{`World`}, Unused: []string{`Old`}},
+		{Lang: `fr`, Missing: []string{`Hello`, `World`}},
+	}, reports)
+	_, err = os.Stat(filepath.Join(dir, `fr.yaml`))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = Sync(config, keys, SyncOptions{Write: true, Prune: true})
+	assert.NoError(t, err)
+	messages, err := LoadMessages(`en`, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, Messages{`Hello`: `Hello`, `World`: `World`}, messages)
+
+	// written to the file defining the catalog, untranslated texts are empty
+	_, err = os.Stat(filepath.Join(dir, `zh-cn.yaml`))
+	assert.True(t, os.IsNotExist(err))
+	messages, err = LoadMessages(`app`, filepath.Join(dir, `zh-cn`))
+	assert.NoError(t, err)
+	assert.Equal(t, Messages{`Hello`: `你好`, `World`: ``}, messages)
+	messages, err = LoadMessages(`fr`, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, Messages{`Hello`: ``, `World`: ``}, messages)
+
+	// the untranslated texts are still missing
+	reports, err = Sync(config, keys, SyncOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*Report{
+		{Lang: `en`},
+		{Lang: `zh-cn`, Missing: []string{`World`}},
+		{Lang: `fr`, Missing: []string{`Hello`, `World`}},
+	}, reports)
+}
@@ -0,0 +1,150 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+// Package catalog 提取源码和模板中的待翻译文本，并与语言包文件进行比对和同步
+package catalog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// quotedString 匹配双引号或反引号包围的字符串字面量
+const quotedString = "(\"(?:[^\"\\\\\\n]|\\\\.)*\"|`[^`]*`)"
+
+var (
+	// c.T("text") c.E(`text`, args...)
+	GoRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`\.(?:T|E)\(\s*` + quotedString),
+	}
+	// {{T "text"}} {{$.T "text" 1}} {{printf "%s" (T "text")}} {{"text"|T}}
+	TemplateRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?:\{\{-?|\(|\|)\s*(?:\$\.)?T\s+` + quotedString),
+		regexp.MustCompile(`\{\{-?\s*` + quotedString + `\s*\|\s*(?:\$\.)?T\s*(?:-?\}\}|\|)`),
+	}
+	// DefaultExtensions 按扩展名选择提取规则
+	DefaultExtensions = map[string][]*regexp.Regexp{
+		`.go`:   GoRegexes,
+		`.html`: TemplateRegexes,
+		`.htm`:  TemplateRegexes,
+		`.tmpl`: TemplateRegexes,
+		`.tpl`:  TemplateRegexes,
+	}
+)
+
+// Location 待翻译文本出现的位置
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return l.File + `:` + strconv.Itoa(l.Line)
+}
+
+// Keys 提取到的待翻译文本及其出现位置
+type Keys map[string][]Location
+
+// Sorted 返回排序后的文本列表
+func (k Keys) Sorted() []string {
+	keys := make([]string, 0, len(k))
+	for key := range k {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Extractor 从源码和模板文件中提取待翻译文本
+type Extractor struct {
+	Extensions map[string][]*regexp.Regexp
+	// SkipDir 返回 true 时跳过该目录。默认跳过以“.”开头的目录和 vendor、node_modules 目录
+	SkipDir func(path string, name string) bool
+}
+
+func NewExtractor() *Extractor {
+	return &Extractor{
+		Extensions: DefaultExtensions,
+		SkipDir:    DefaultSkipDir,
+	}
+}
+
+func DefaultSkipDir(_ string, name string) bool {
+	return (len(name) > 1 && strings.HasPrefix(name, `.`)) || name == `vendor` || name == `node_modules`
+}
+
+// Extract 遍历目录(或文件)提取待翻译文本
+func (e *Extractor) Extract(paths ...string) (Keys, error) {
+	keys := Keys{}
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != root && e.SkipDir != nil && e.SkipDir(path, info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			regexes, ok := e.Extensions[strings.ToLower(filepath.Ext(path))]
+			if !ok {
+				return nil
+			}
+			if strings.HasSuffix(path, `_test.go`) {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			e.ExtractContent(keys, path, content, regexes)
+			return nil
+		})
+		if err != nil {
+			return keys, err
+		}
+	}
+	return keys, nil
+}
+
+// ExtractContent 使用 regexes 从 content 中提取待翻译文本并添加到 keys 中
+func (e *Extractor) ExtractContent(keys Keys, file string, content []byte, regexes []*regexp.Regexp) {
+	for _, re := range regexes {
+		for _, m := range re.FindAllSubmatchIndex(content, -1) {
+			key, err := unquote(string(content[m[2]:m[3]]))
+			if err != nil || len(key) == 0 {
+				continue
+			}
+			line := bytes.Count(content[:m[2]], []byte{'\n'}) + 1
+			keys[key] = append(keys[key], Location{File: file, Line: line})
+		}
+	}
+}
+
+func unquote(s string) (string, error) {
+	if strings.HasPrefix(s, "`") {
+		return strings.Trim(s, "`"), nil
+	}
+	return strconv.Unquote(s)
+}
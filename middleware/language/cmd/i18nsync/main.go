@@ -0,0 +1,59 @@
+// 提取源码和模板中的待翻译文本，与语言包比对并报告缺失和未使用的文本
+//
+//	go run github.com/webx-top/echo/middleware/language/cmd/i18nsync -src . -messages ./messages -langs zh-cn,en -write
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/webx-top/echo/middleware/language"
+	"github.com/webx-top/echo/middleware/language/catalog"
+)
+
+func main() {
+	src := flag.String(`src`, `.`, `分析目录(多个用逗号分隔)`)
+	messages := flag.String(`messages`, `./messages`, `语言包目录(多个用逗号分隔，新增文本写入第一个目录)`)
+	langs := flag.String(`langs`, `zh-cn,en`, `语种列表(用逗号分隔)`)
+	write := flag.Bool(`write`, false, `是否将缺失的文本写入语言包文件`)
+	prune := flag.Bool(`prune`, false, `是否删除语言包中未使用的文本(需同时指定 -write)`)
+	verbose := flag.Bool(`v`, false, `是否显示文本出现的位置`)
+	strict := flag.Bool(`strict`, false, `存在缺失的文本时以非零状态码退出`)
+	flag.Parse()
+
+	keys, err := catalog.NewExtractor().Extract(catalog.LangsOf(*src)...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	config := &language.Config{
+		AllList:      catalog.LangsOf(*langs),
+		MessagesPath: catalog.LangsOf(*messages),
+	}
+	reports, err := catalog.Sync(config, keys, catalog.SyncOptions{Write: *write, Prune: *prune})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Printf("found %d keys\n", len(keys))
+	var missing int
+	for _, r := range reports {
+		fmt.Printf("[%s] missing: %d, unused: %d\n", r.Lang, len(r.Missing), len(r.Unused))
+		for _, key := range r.Missing {
+			fmt.Printf("  + %q\n", key)
+			if *verbose {
+				for _, loc := range keys[key] {
+					fmt.Printf("      %s\n", loc)
+				}
+			}
+		}
+		for _, key := range r.Unused {
+			fmt.Printf("  - %q\n", key)
+		}
+		missing += len(r.Missing)
+	}
+	if *strict && missing > 0 && !*write {
+		os.Exit(1)
+	}
+}
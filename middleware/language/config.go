@@ -25,7 +25,11 @@ type Config struct {
 	RulesPath    []string
 	MessagesPath []string
 	Reload       bool
-	fsFunc       func(string) http.FileSystem
+	// Fallbacks 语种回退链。例如: {"zh-hk": ["zh-tw", "zh"]}
+	Fallbacks map[string][]string
+	// LogMissing 记录并输出运行时遇到的未翻译文本(通过 I18n.Missing 获取)
+	LogMissing bool
+	fsFunc     func(string) http.FileSystem
 }
 
 func (c *Config) SetFSFunc(fsFunc func(string) http.FileSystem) *Config {
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/admpub/log"
 	"github.com/webx-top/com"
 	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/param"
 )
 
 var defaultInstance *I18n
@@ -34,6 +36,8 @@ type I18n struct {
 	*i18n.TranslatorFactory
 	lock        sync.RWMutex
 	translators map[string]*i18n.Translator
+	formats     map[string]map[string]*MessageFormat
+	missing     map[string]map[string]struct{}
 	config      *Config
 }
 
@@ -84,6 +88,8 @@ func NewI18n(c *Config) *I18n {
 	defaultInstance = &I18n{
 		TranslatorFactory: f,
 		translators:       make(map[string]*i18n.Translator),
+		formats:           make(map[string]map[string]*MessageFormat),
+		missing:           make(map[string]map[string]struct{}),
 		config:            c,
 	}
 	defaultInstance.GetAndCache(c.Default)
@@ -154,6 +160,8 @@ func (a *I18n) Reload(langCode string) {
 
 	a.lock.Lock()
 	delete(a.translators, langCode)
+	delete(a.formats, langCode)
+	delete(a.missing, langCode)
 	a.lock.Unlock()
 }
 
@@ -168,7 +176,13 @@ func (a *I18n) Get(langCode string) *i18n.Translator {
 }
 
 func (a *I18n) Translate(langCode, key string, args map[string]string) string {
-	t := a.Get(langCode)
+	if a.config.LogMissing {
+		a.checkMissing(langCode, key)
+	}
+	t := a.translator(langCode, key)
+	if t == nil {
+		return key
+	}
 	translation, err := t.Translate(key, args)
 	if err != nil {
 		return key
@@ -176,11 +190,115 @@ func (a *I18n) Translate(langCode, key string, args map[string]string) string {
 	return translation
 }
 
+// translator 返回 key 的消息不为空的第一个翻译器(包括回退语种)。空消息表示尚未翻译
+func (a *I18n) translator(langCode, key string) *i18n.Translator {
+	for t := a.Get(langCode); t != nil; t = t.Fallback() {
+		if message := t.Messages()[key]; len(message) > 0 {
+			return t
+		}
+		if t.Fallback() == t {
+			break
+		}
+	}
+	return nil
+}
+
+// Message 返回 key 对应的原始消息文本(包括回退语种中的消息)
+func (a *I18n) Message(langCode, key string) (string, bool) {
+	if t := a.translator(langCode, key); t != nil {
+		return t.Messages()[key], true
+	}
+	return key, false
+}
+
+// checkMissing 只检查 langCode 自身的语言包(不查找回退语种)，消息为空的视为缺失
+func (a *I18n) checkMissing(langCode, key string) {
+	if t := a.Get(langCode); t != nil {
+		if message := t.Messages()[key]; len(message) > 0 {
+			return
+		}
+	}
+	a.lock.Lock()
+	if a.missing[langCode] == nil {
+		a.missing[langCode] = map[string]struct{}{}
+	}
+	_, logged := a.missing[langCode][key]
+	if !logged {
+		a.missing[langCode][key] = struct{}{}
+	}
+	a.lock.Unlock()
+	if !logged {
+		log.Warnf(`untranslated i18n message (%s): %q`, langCode, key)
+	}
+}
+
+// Missing 返回运行时遇到的未翻译文本(按语种分组)。需要启用 Config.LogMissing
+func (a *I18n) Missing() map[string][]string {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	result := make(map[string][]string, len(a.missing))
+	for langCode, keys := range a.missing {
+		list := make([]string, 0, len(keys))
+		for key := range keys {
+			list = append(list, key)
+		}
+		sort.Strings(list)
+		result[langCode] = list
+	}
+	return result
+}
+
+// MessageFormat 返回 key 对应的已编译 ICU MessageFormat 消息
+func (a *I18n) MessageFormat(langCode, key string) (*MessageFormat, error) {
+	a.lock.RLock()
+	mf, ok := a.formats[langCode][key]
+	a.lock.RUnlock()
+	if ok {
+		return mf, nil
+	}
+	message, _ := a.Message(langCode, key)
+	mf, err := NewMessageFormat(langCode, message)
+	if err != nil {
+		return nil, err
+	}
+	a.lock.Lock()
+	if a.formats[langCode] == nil {
+		a.formats[langCode] = map[string]*MessageFormat{}
+	}
+	a.formats[langCode][key] = mf
+	a.lock.Unlock()
+	return mf, nil
+}
+
+// Format 使用 ICU MessageFormat 语法(plural、select、number、date 等)翻译并格式化消息
+func (a *I18n) Format(langCode, key string, args map[string]interface{}) string {
+	if a.config.LogMissing {
+		a.checkMissing(langCode, key)
+	}
+	mf, err := a.MessageFormat(langCode, key)
+	if err != nil {
+		log.Warnf(`failed to parse i18n message %q (%s): %v`, key, langCode, err)
+		return key
+	}
+	t, err := mf.Format(args, a.Get(langCode))
+	if err != nil {
+		log.Warnf(`failed to format i18n message %q (%s): %v`, key, langCode, err)
+	}
+	return t
+}
+
 func (a *I18n) T(langCode, key string, args ...interface{}) (t string) {
 	if len(args) > 0 {
-		if v, ok := args[0].(map[string]string); ok {
+		switch v := args[0].(type) {
+		case map[string]string:
 			t = a.Translate(langCode, key, v)
 			return
+		case map[string]interface{}:
+			t = a.Format(langCode, key, v)
+			return
+		case param.Store:
+			t = a.Format(langCode, key, v)
+			return
 		}
 		t = a.Translate(langCode, key, map[string]string{})
 		t = fmt.Sprintf(t, args...)
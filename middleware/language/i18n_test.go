@@ -0,0 +1,39 @@
+package language
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestI18nUntranslated(t *testing.T) {
+	rules, messages := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(rules, `en.yaml`):       "plural : \"2A\"\ndirection : \"LTR\"\n",
+		filepath.Join(rules, `zh-cn.yaml`):    "plural : \"1\"\ndirection : \"LTR\"\n",
+		filepath.Join(messages, `en.yaml`):    "Hello : \"Hello\"\nBye : \"Bye\"\n",
+		filepath.Join(messages, `zh-cn.yaml`): "Hello : \"你好\"\nBye : \"\"\n",
+	}
+	for file, content := range files {
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+	a := NewI18n(&Config{
+		Default:      `en`,
+		AllList:      []string{`en`, `zh-cn`},
+		RulesPath:    []string{rules},
+		MessagesPath: []string{messages},
+		LogMissing:   true,
+	})
+	assert.Equal(t, `你好`, a.T(`zh-cn`, `Hello`))
+	// the empty message is untranslated
+	assert.Equal(t, `Bye`, a.T(`zh-cn`, `Bye`))
+	message, ok := a.Message(`zh-cn`, `Bye`)
+	assert.False(t, ok)
+	assert.Equal(t, `Bye`, message)
+	message, ok = a.Message(`zh-cn`, `Hello`)
+	assert.True(t, ok)
+	assert.Equal(t, `你好`, message)
+	assert.Equal(t, map[string][]string{`zh-cn`: {`Bye`}}, a.Missing())
+}
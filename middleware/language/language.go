@@ -19,7 +19,6 @@ package language
 
 import (
 	"bytes"
-	"regexp"
 	"strings"
 	"sync"
 
@@ -29,9 +28,8 @@ import (
 )
 
 var (
-	LangVarName        = `lang`
-	DefaultLang        = `zh-cn`
-	headerAcceptRemove = regexp.MustCompile(`;q=[0-9.]+`)
+	LangVarName = `lang`
+	DefaultLang = `zh-cn`
 )
 
 func New(c ...*Config) *Language {
@@ -52,11 +50,13 @@ func New(c ...*Config) *Language {
 }
 
 type Language struct {
-	List          map[string]bool //语种列表
-	Index         []string        //索引
-	Default       string          //默认语种
+	List          map[string]bool     //语种列表
+	Index         []string            //索引
+	Default       string              //默认语种
+	Fallbacks     map[string][]string //语种回退链
 	I18n          *I18n
 	translatePool sync.Pool
+	profileFunc   func(echo.Context) string
 }
 
 func (a *Language) Init(c *Config) {
@@ -70,6 +70,12 @@ func (a *Language) Init(c *Config) {
 			a.Set(`en`, true)
 		}
 	}
+	if c.Fallbacks != nil {
+		a.Fallbacks = make(map[string][]string, len(c.Fallbacks))
+		for lang, chain := range c.Fallbacks {
+			a.Fallbacks[NormalizeTag(lang)] = chain
+		}
+	}
 	a.I18n = NewI18n(c)
 	if c.Reload {
 		a.I18n.Monitor()
@@ -90,6 +96,39 @@ func (a *Language) Set(lang string, on bool, args ...bool) *Language {
 	return a
 }
 
+// SetProfileFunc 设置从用户资料中获取语种的函数(例如读取已登录用户的偏好设置)。
+// 优先级低于 URL 参数、网址路径和 Cookie，高于 Accept-Language 头
+func (a *Language) SetProfileFunc(fn func(echo.Context) string) *Language {
+	a.profileFunc = fn
+	return a
+}
+
+// Match 根据回退链和 RFC 4647 查找规则返回与 lang 最匹配的有效语种，
+// 没有匹配时返回空字符串
+func (a *Language) Match(lang string) string {
+	if len(lang) == 0 {
+		return ``
+	}
+	if a.Valid(lang) {
+		return lang
+	}
+	return Lookup([]string{lang}, a.Valid, a.Fallbacks)
+}
+
+// Negotiate 按优先级顺序在 ranges 中协商语种。
+// 先对每个语言范围执行 lookup(含回退链)，失败时再执行基础过滤(例如 zh 匹配 zh-cn)
+func (a *Language) Negotiate(ranges ...string) string {
+	if found := Lookup(ranges, a.Valid, a.Fallbacks); len(found) > 0 {
+		return found
+	}
+	for _, lang := range Filter(ranges, a.Index) {
+		if a.Valid(lang) {
+			return lang
+		}
+	}
+	return ``
+}
+
 func (a *Language) DetectURI(r engine.Request) string {
 	p := strings.TrimPrefix(r.URL().Path(), `/`)
 	s := strings.Index(p, `/`)
@@ -104,7 +143,14 @@ func (a *Language) DetectURI(r engine.Request) string {
 	}
 	on, ok := a.List[lang]
 	if !ok {
-		return ``
+		// 只剥离 Fallbacks 中显式声明的语种标签，不做截短匹配，
+		// 以免把 /en-route/ 之类的普通路径误当作语种
+		matched := a.matchFallback(lang)
+		if len(matched) == 0 {
+			return ``
+		}
+		r.URL().SetPath(strings.TrimPrefix(p, lang))
+		return matched
 	}
 	r.URL().SetPath(strings.TrimPrefix(p, lang))
 	if !on {
@@ -113,6 +159,21 @@ func (a *Language) DetectURI(r engine.Request) string {
 	return lang
 }
 
+// matchFallback 返回 lang 在回退链中第一个有效的语种，lang 未在 Fallbacks 中声明时返回空字符串
+func (a *Language) matchFallback(lang string) string {
+	fallbacks, ok := a.Fallbacks[NormalizeTag(lang)]
+	if !ok {
+		return ``
+	}
+	for _, fb := range fallbacks {
+		fb = NormalizeTag(fb)
+		if a.Valid(fb) {
+			return fb
+		}
+	}
+	return ``
+}
+
 func (a *Language) Valid(lang string) bool {
 	if len(lang) == 0 {
 		return false
@@ -123,25 +184,23 @@ func (a *Language) Valid(lang string) bool {
 	return false
 }
 
+// ParseHeader 解析 Accept-Language 头，返回按 q 值排序的前 n 个语言范围(n<0 时返回全部)
 func ParseHeader(al string, n int) []string {
-	if len(al) == 0 {
-		return []string{}
+	accepts := ParseAcceptLanguage(al)
+	if n >= 0 && len(accepts) > n {
+		accepts = accepts[:n]
+	}
+	ranges := make([]string, len(accepts))
+	for i, v := range accepts {
+		ranges[i] = v.Range
 	}
-	al = headerAcceptRemove.ReplaceAllString(al, ``)
-	return strings.SplitN(al, `,`, n)
+	return ranges
 }
 
 func (a *Language) DetectHeader(r engine.Request) string {
-	lg := ParseHeader(r.Header().Get(`Accept-Language`), 5)
-	for _, lang := range lg {
-		lang = strings.ToLower(lang)
-		if a.Valid(lang) {
-			return lang
-		}
-		lang = strings.SplitN(lang, `-`, 2)[0]
-		if a.Valid(lang) {
-			return lang
-		}
+	lg := ParseHeader(r.Header().Get(`Accept-Language`), -1)
+	if lang := a.Negotiate(lg...); len(lang) > 0 {
+		return lang
 	}
 	return a.Default
 }
@@ -149,16 +208,22 @@ func (a *Language) DetectHeader(r engine.Request) string {
 func (a *Language) Middleware() echo.MiddlewareFunc {
 	return echo.MiddlewareFunc(func(h echo.Handler) echo.Handler {
 		return echo.HandlerFunc(func(c echo.Context) error {
-			lang := c.Query(LangVarName)
+			lang := a.Match(c.Query(LangVarName))
 			var hasCookie bool
-			if !a.Valid(lang) {
+			if len(lang) == 0 {
 				lang = a.DetectURI(c.Request())
 				if !a.Valid(lang) {
-					lang = c.GetCookie(LangVarName)
-					if !a.Valid(lang) {
-						lang = a.DetectHeader(c.Request())
+					cookieLang := c.GetCookie(LangVarName)
+					lang = a.Match(cookieLang)
+					if len(lang) == 0 {
+						if a.profileFunc != nil {
+							lang = a.Match(a.profileFunc(c))
+						}
+						if len(lang) == 0 {
+							lang = a.DetectHeader(c.Request())
+						}
 					} else {
-						hasCookie = true
+						hasCookie = lang == cookieLang
 					}
 				}
 			}
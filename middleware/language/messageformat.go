@@ -0,0 +1,530 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package language
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/admpub/i18n"
+)
+
+var ErrMessageFormatSyntax = errors.New(`messageformat: syntax error`)
+
+// MessageFormat 已编译的 ICU MessageFormat 消息
+// 支持: {name}、{n, number[, integer|percent|currency/USD]}、{d, date|time[, short|medium|long|full]}、
+// {n, plural, [offset:1] =0 {...} one {...} other {...}}、{n, selectordinal, ...}、
+// {gender, select, male {...} other {...}} 以及在 plural 中用“#”引用数值
+type MessageFormat struct {
+	lang  string
+	nodes []mfNode
+}
+
+// NewMessageFormat 编译 ICU MessageFormat 格式的消息
+func NewMessageFormat(lang string, message string) (*MessageFormat, error) {
+	p := &mfParser{src: []rune(message)}
+	nodes, err := p.parseMessage(false, 0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf(`unexpected %q`, p.src[p.pos])
+	}
+	return &MessageFormat{lang: lang, nodes: nodes}, nil
+}
+
+// Format 使用参数格式化消息。translator 用于按语种格式化数字和日期，可以为 nil
+func (m *MessageFormat) Format(args map[string]interface{}, translator *i18n.Translator) (string, error) {
+	f := &mfFormatter{lang: m.lang, args: args, translator: translator}
+	var b strings.Builder
+	if err := f.format(&b, m.nodes, nil); err != nil {
+		return b.String(), err
+	}
+	return b.String(), nil
+}
+
+type mfNode interface{}
+
+type mfText string
+
+type mfPound struct{}
+
+type mfArg struct {
+	name  string
+	typ   string
+	style string
+}
+
+type mfPlural struct {
+	name    string
+	ordinal bool
+	offset  float64
+	options map[string][]mfNode
+}
+
+type mfSelect struct {
+	name    string
+	options map[string][]mfNode
+}
+
+type mfParser struct {
+	src []rune
+	pos int
+}
+
+func (p *mfParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf(`%w at offset %d: %s`, ErrMessageFormatSyntax, p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseMessage 解析消息文本直至遇到未配对的“}”或结尾。inPlural 表示“#”是否有特殊含义
+func (p *mfParser) parseMessage(inPlural bool, depth int) ([]mfNode, error) {
+	var (
+		nodes []mfNode
+		text  strings.Builder
+	)
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, mfText(text.String()))
+			text.Reset()
+		}
+	}
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == '\'':
+			p.pos++
+			if p.pos < len(p.src) && p.src[p.pos] == '\'' {
+				text.WriteRune('\'')
+				p.pos++
+				continue
+			}
+			if p.pos < len(p.src) && (p.src[p.pos] == '{' || p.src[p.pos] == '}' || (inPlural && p.src[p.pos] == '#')) {
+				for p.pos < len(p.src) {
+					if p.src[p.pos] == '\'' {
+						if p.pos+1 < len(p.src) && p.src[p.pos+1] == '\'' {
+							text.WriteRune('\'')
+							p.pos += 2
+							continue
+						}
+						p.pos++
+						break
+					}
+					text.WriteRune(p.src[p.pos])
+					p.pos++
+				}
+				continue
+			}
+			text.WriteRune('\'')
+		case r == '{':
+			flush()
+			p.pos++
+			node, err := p.parseArgument(inPlural, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case r == '}':
+			if depth == 0 {
+				return nil, p.errorf(`unmatched "}"`)
+			}
+			flush()
+			return nodes, nil
+		case r == '#' && inPlural:
+			flush()
+			nodes = append(nodes, mfPound{})
+			p.pos++
+		default:
+			text.WriteRune(r)
+			p.pos++
+		}
+	}
+	flush()
+	return nodes, nil
+}
+
+func (p *mfParser) skipSpace() {
+	for p.pos < len(p.src) && isMFSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func isMFSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// parseWord 读取一个由非空白且非语法字符组成的单词
+func (p *mfParser) parseWord() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if isMFSpace(r) || r == ',' || r == '{' || r == '}' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *mfParser) expect(r rune) error {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != r {
+		return p.errorf(`expected %q`, r)
+	}
+	p.pos++
+	return nil
+}
+
+// parseArgument 解析“{”之后的参数，结束于对应的“}”
+func (p *mfParser) parseArgument(inPlural bool, depth int) (mfNode, error) {
+	name := p.parseWord()
+	if len(name) == 0 {
+		return nil, p.errorf(`missing argument name`)
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '}' {
+		p.pos++
+		return mfArg{name: name}, nil
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	typ := p.parseWord()
+	switch typ {
+	case `plural`, `selectordinal`:
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		node := mfPlural{name: name, ordinal: typ == `selectordinal`}
+		options, err := p.parseOptions(true, depth, func(key string) error {
+			if !strings.HasPrefix(key, `offset:`) {
+				return errNotOffset
+			}
+			offset, err := strconv.ParseFloat(strings.TrimPrefix(key, `offset:`), 64)
+			if err != nil {
+				return p.errorf(`invalid offset %q`, key)
+			}
+			node.offset = offset
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		node.options = options
+		return node, nil
+	case `select`:
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		options, err := p.parseOptions(inPlural, depth, nil)
+		if err != nil {
+			return nil, err
+		}
+		return mfSelect{name: name, options: options}, nil
+	case `number`, `date`, `time`:
+	default:
+		return nil, p.errorf(`unknown argument type %q`, typ)
+	}
+	arg := mfArg{name: name, typ: typ}
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == ',' {
+		p.pos++
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] != '}' {
+			p.pos++
+		}
+		arg.style = strings.TrimSpace(string(p.src[start:p.pos]))
+	}
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return arg, nil
+}
+
+var errNotOffset = errors.New(`not offset`)
+
+func (p *mfParser) parseOptions(inPlural bool, depth int, onKeyword func(string) error) (map[string][]mfNode, error) {
+	options := map[string][]mfNode{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf(`unterminated argument`)
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			break
+		}
+		key := p.parseWord()
+		if len(key) == 0 {
+			return nil, p.errorf(`missing option key`)
+		}
+		if onKeyword != nil && len(options) == 0 {
+			err := onKeyword(key)
+			if err == nil {
+				continue
+			}
+			if err != errNotOffset {
+				return nil, err
+			}
+		}
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		nodes, err := p.parseMessage(inPlural, depth)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+		options[key] = nodes
+	}
+	if _, ok := options[PluralOther]; !ok {
+		return nil, p.errorf(`missing "other" option`)
+	}
+	return options, nil
+}
+
+type mfFormatter struct {
+	lang       string
+	args       map[string]interface{}
+	translator *i18n.Translator
+}
+
+type mfPoundValue struct {
+	value float64
+	str   string
+}
+
+func (f *mfFormatter) format(b *strings.Builder, nodes []mfNode, pound *mfPoundValue) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case mfText:
+			b.WriteString(string(n))
+		case mfPound:
+			if pound == nil {
+				b.WriteRune('#')
+				continue
+			}
+			b.WriteString(f.formatNumber(pound.value, pound.str, ``))
+		case mfArg:
+			v, ok := f.args[n.name]
+			if !ok {
+				b.WriteString(`{` + n.name + `}`)
+				continue
+			}
+			s, err := f.formatArg(n, v)
+			if err != nil {
+				return err
+			}
+			b.WriteString(s)
+		case mfSelect:
+			key := fmt.Sprint(f.args[n.name])
+			opt, ok := n.options[key]
+			if !ok {
+				opt = n.options[PluralOther]
+			}
+			if err := f.format(b, opt, pound); err != nil {
+				return err
+			}
+		case mfPlural:
+			value, str, err := toNumber(f.args[n.name])
+			if err != nil {
+				return fmt.Errorf(`messageformat: argument %q: %w`, n.name, err)
+			}
+			opt, ok := n.options[`=`+str]
+			if !ok {
+				opt, ok = n.options[`=`+strconv.FormatFloat(value, 'f', -1, 64)]
+			}
+			value -= n.offset
+			if n.offset != 0 {
+				str = strconv.FormatFloat(value, 'f', -1, 64)
+			}
+			if !ok {
+				op, err := NewPluralOperands(str)
+				if err != nil {
+					return err
+				}
+				var category string
+				if n.ordinal {
+					category = OrdinalCategory(f.lang, op)
+				} else {
+					category = PluralCategory(f.lang, op)
+				}
+				opt, ok = n.options[category]
+				if !ok {
+					opt = n.options[PluralOther]
+				}
+			}
+			if err := f.format(b, opt, &mfPoundValue{value: value, str: str}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *mfFormatter) formatArg(n mfArg, v interface{}) (string, error) {
+	switch n.typ {
+	case `number`:
+		value, str, err := toNumber(v)
+		if err != nil {
+			return ``, fmt.Errorf(`messageformat: argument %q: %w`, n.name, err)
+		}
+		return f.formatNumber(value, str, n.style), nil
+	case `date`, `time`:
+		t, err := toTime(v)
+		if err != nil {
+			return ``, fmt.Errorf(`messageformat: argument %q: %w`, n.name, err)
+		}
+		return f.formatTime(t, n.typ, n.style), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func (f *mfFormatter) formatNumber(value float64, str string, style string) string {
+	style = strings.TrimPrefix(style, `::`)
+	if f.translator == nil {
+		switch style {
+		case `integer`:
+			return strconv.FormatFloat(value, 'f', 0, 64)
+		case `percent`:
+			return strconv.FormatFloat(value*100, 'f', 0, 64) + `%`
+		}
+		return str
+	}
+	switch {
+	case style == `integer`:
+		return f.translator.FormatNumberWhole(value)
+	case style == `percent`:
+		return f.translator.FormatPercent(value)
+	case strings.HasPrefix(style, `currency/`):
+		s, _ := f.translator.FormatCurrency(value, strings.TrimPrefix(style, `currency/`))
+		return s
+	}
+	return f.translator.FormatNumber(value)
+}
+
+var mfDateFormats = map[string]map[string]int{
+	`date`: {
+		`full`:   i18n.DateFormatFull,
+		`long`:   i18n.DateFormatLong,
+		`medium`: i18n.DateFormatMedium,
+		`short`:  i18n.DateFormatShort,
+	},
+	`time`: {
+		`full`:   i18n.TimeFormatFull,
+		`long`:   i18n.TimeFormatLong,
+		`medium`: i18n.TimeFormatMedium,
+		`short`:  i18n.TimeFormatShort,
+	},
+}
+
+var mfDateLayouts = map[string]map[string]string{
+	`date`: {
+		`full`:   `Monday, January 2, 2006`,
+		`long`:   `January 2, 2006`,
+		`medium`: `Jan 2, 2006`,
+		`short`:  `1/2/06`,
+	},
+	`time`: {
+		`full`:   `3:04:05 PM MST`,
+		`long`:   `3:04:05 PM MST`,
+		`medium`: `3:04:05 PM`,
+		`short`:  `3:04 PM`,
+	},
+}
+
+func (f *mfFormatter) formatTime(t time.Time, typ string, style string) string {
+	if len(style) == 0 {
+		style = `medium`
+	}
+	if format, ok := mfDateFormats[typ][style]; ok {
+		if f.translator != nil {
+			if s, err := f.translator.FormatDateTime(format, t); err == nil && len(s) > 0 {
+				return s
+			}
+		}
+		return t.Format(mfDateLayouts[typ][style])
+	}
+	// 其它样式视为 Go 的时间布局
+	return t.Format(style)
+}
+
+func toNumber(v interface{}) (float64, string, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), strconv.Itoa(n), nil
+	case int8:
+		return float64(n), strconv.FormatInt(int64(n), 10), nil
+	case int16:
+		return float64(n), strconv.FormatInt(int64(n), 10), nil
+	case int32:
+		return float64(n), strconv.FormatInt(int64(n), 10), nil
+	case int64:
+		return float64(n), strconv.FormatInt(n, 10), nil
+	case uint:
+		return float64(n), strconv.FormatUint(uint64(n), 10), nil
+	case uint8:
+		return float64(n), strconv.FormatUint(uint64(n), 10), nil
+	case uint16:
+		return float64(n), strconv.FormatUint(uint64(n), 10), nil
+	case uint32:
+		return float64(n), strconv.FormatUint(uint64(n), 10), nil
+	case uint64:
+		return float64(n), strconv.FormatUint(n, 10), nil
+	case float32:
+		return float64(n), strconv.FormatFloat(float64(n), 'f', -1, 32), nil
+	case float64:
+		return n, strconv.FormatFloat(n, 'f', -1, 64), nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, n, err
+	case fmt.Stringer:
+		return toNumber(n.String())
+	case nil:
+		return 0, ``, errors.New(`missing number`)
+	}
+	return 0, ``, fmt.Errorf(`unsupported number type %T`, v)
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case *time.Time:
+		if t != nil {
+			return *t, nil
+		}
+	case int:
+		return time.Unix(int64(t), 0), nil
+	case int64:
+		return time.Unix(t, 0), nil
+	case uint:
+		return time.Unix(int64(t), 0), nil
+	case uint64:
+		return time.Unix(int64(t), 0), nil
+	}
+	return time.Time{}, fmt.Errorf(`unsupported time type %T`, v)
+}
@@ -0,0 +1,59 @@
+package language
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustFormat(t *testing.T, lang, message string, args map[string]interface{}) string {
+	mf, err := NewMessageFormat(lang, message)
+	assert.NoError(t, err)
+	r, err := mf.Format(args, nil)
+	assert.NoError(t, err)
+	return r
+}
+
+func TestPluralCategory(t *testing.T) {
+	cases := map[string]map[string]string{
+		`ru`: {`1`: PluralOne, `21`: PluralOne, `3`: PluralFew, `11`: PluralMany, `25`: PluralMany, `1.5`: PluralOther},
+		`pl`: {`1`: PluralOne, `22`: PluralFew, `12`: PluralMany, `21`: PluralMany, `0.5`: PluralOther},
+		`ar`: {`0`: PluralZero, `1`: PluralOne, `2`: PluralTwo, `105`: PluralFew, `111`: PluralMany, `100`: PluralOther},
+		`en`: {`1`: PluralOne, `1.0`: PluralOther, `2`: PluralOther},
+		`fr`: {`0`: PluralOne, `1.5`: PluralOne, `2`: PluralOther},
+		`zh`: {`1`: PluralOther},
+	}
+	for lang, numbers := range cases {
+		for number, expected := range numbers {
+			op, err := NewPluralOperands(number)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, PluralCategory(lang, op), lang+`: `+number)
+		}
+	}
+	op, _ := NewPluralOperands(`22`)
+	assert.Equal(t, PluralTwo, OrdinalCategory(`en-US`, op))
+}
+
+func TestMessageFormat(t *testing.T) {
+	ru := `{count, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}`
+	assert.Equal(t, `1 файл`, mustFormat(t, `ru`, ru, map[string]interface{}{`count`: 1}))
+	assert.Equal(t, `3 файла`, mustFormat(t, `ru`, ru, map[string]interface{}{`count`: 3}))
+	assert.Equal(t, `11 файлов`, mustFormat(t, `ru`, ru, map[string]interface{}{`count`: 11}))
+
+	msg := `{gender, select, female {{name} invited # friend of hers} other {{name} invited {n, plural, offset:1 =0 {nobody} =1 {one person} one {# other and you} other {# others and you}}}}`
+	assert.Equal(t, `Ann invited # friend of hers`, mustFormat(t, `en`, msg, map[string]interface{}{`gender`: `female`, `name`: `Ann`}))
+	assert.Equal(t, `Bob invited nobody`, mustFormat(t, `en`, msg, map[string]interface{}{`gender`: `male`, `name`: `Bob`, `n`: 0}))
+	assert.Equal(t, `Bob invited 1 other and you`, mustFormat(t, `en`, msg, map[string]interface{}{`gender`: `male`, `name`: `Bob`, `n`: 2}))
+	assert.Equal(t, `Bob invited 4 others and you`, mustFormat(t, `en`, msg, map[string]interface{}{`gender`: `male`, `name`: `Bob`, `n`: 5}))
+
+	assert.Equal(t, `the 23rd time`, mustFormat(t, `en`, `the {n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}} time`, map[string]interface{}{`n`: 23}))
+	assert.Equal(t, `It's {quoted} 50%`, mustFormat(t, `en`, `It''s '{quoted}' {p, number, percent}`, map[string]interface{}{`p`: 0.5}))
+	assert.Equal(t, `Jan 2, 2006`, mustFormat(t, `en`, `{d, date}`, map[string]interface{}{`d`: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)}))
+	assert.Equal(t, `hello {missing}`, mustFormat(t, `en`, `hello {missing}`, nil))
+
+	_, err := NewMessageFormat(`en`, `{n, plural, one {x}}`)
+	assert.ErrorIs(t, err, ErrMessageFormatSyntax)
+	_, err = NewMessageFormat(`en`, `{n, plural, other {x}`)
+	assert.ErrorIs(t, err, ErrMessageFormatSyntax)
+}
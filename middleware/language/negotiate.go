@@ -0,0 +1,158 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package language
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AcceptLanguage Accept-Language 中的一个语言范围(language-range)及其权重
+type AcceptLanguage struct {
+	Range   string
+	Quality float64
+}
+
+// ParseAcceptLanguage 解析 Accept-Language 头(RFC 7231 §5.3.5)。
+// 结果按 q 值从高到低排序(q 值相同时保持原有顺序)，q=0 的语言范围会被丢弃。
+// 语言范围统一转为小写，下划线转为连字符
+func ParseAcceptLanguage(header string) []AcceptLanguage {
+	if len(header) == 0 {
+		return []AcceptLanguage{}
+	}
+	parts := strings.Split(header, `,`)
+	result := make([]AcceptLanguage, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		al := AcceptLanguage{Quality: 1}
+		params := strings.Split(part, `;`)
+		al.Range = NormalizeTag(params[0])
+		if len(al.Range) == 0 {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) < 2 || (param[0] != 'q' && param[0] != 'Q') || param[1] != '=' {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(param[2:]), 64)
+			if err != nil || q < 0 {
+				q = 0
+			} else if q > 1 {
+				q = 1
+			}
+			al.Quality = q
+		}
+		if al.Quality <= 0 {
+			continue
+		}
+		result = append(result, al)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Quality > result[j].Quality
+	})
+	return result
+}
+
+// NormalizeTag 规范化语言标签：去除首尾空白、转为小写并将下划线替换为连字符
+func NormalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.ToLower(tag)
+	return strings.ReplaceAll(tag, `_`, `-`)
+}
+
+// Truncate 按 RFC 4647 §3.4 截短语言标签：去掉最后一个子标签，
+// 如果剩余部分以单字符子标签结尾也一并去掉。无法再截短时返回空字符串
+func Truncate(tag string) string {
+	pos := strings.LastIndex(tag, `-`)
+	if pos <= 0 {
+		return ``
+	}
+	tag = tag[:pos]
+	if pos = strings.LastIndex(tag, `-`); pos > 0 && len(tag)-pos == 2 {
+		tag = tag[:pos]
+	}
+	return tag
+}
+
+// MatchBasic 基础过滤(RFC 4647 §3.3.1)：语言范围与标签相同，
+// 或者是标签的前缀且紧随其后的是“-”时返回 true。“*”匹配任何标签
+func MatchBasic(languageRange string, tag string) bool {
+	if languageRange == `*` {
+		return true
+	}
+	if len(tag) < len(languageRange) || !strings.EqualFold(tag[:len(languageRange)], languageRange) {
+		return false
+	}
+	return len(tag) == len(languageRange) || tag[len(languageRange)] == '-'
+}
+
+// Filter 返回 tags 中与任一语言范围匹配的标签(RFC 4647 §3.3.1)，
+// 结果按 ranges 的优先级顺序排列且不重复
+func Filter(ranges []string, tags []string) []string {
+	var result []string
+	seen := map[string]struct{}{}
+	for _, r := range ranges {
+		r = NormalizeTag(r)
+		for _, tag := range tags {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			if MatchBasic(r, tag) {
+				seen[tag] = struct{}{}
+				result = append(result, tag)
+			}
+		}
+	}
+	return result
+}
+
+// Lookup 按 RFC 4647 §3.4 查找：依次对每个语言范围进行逐级截短，
+// 返回第一个 valid 函数认可的标签。fallbacks 为每个标签额外指定的回退链，
+// 会在截短之前尝试(例如 zh-hk → zh-tw → zh)
+func Lookup(ranges []string, valid func(string) bool, fallbacks map[string][]string) string {
+	for _, r := range ranges {
+		if found := lookupRange(NormalizeTag(r), valid, fallbacks); len(found) > 0 {
+			return found
+		}
+	}
+	return ``
+}
+
+func lookupRange(tag string, valid func(string) bool, fallbacks map[string][]string) string {
+	if tag == `*` {
+		return ``
+	}
+	for ; len(tag) > 0; tag = Truncate(tag) {
+		if valid(tag) {
+			return tag
+		}
+		for _, fb := range fallbacks[tag] {
+			fb = NormalizeTag(fb)
+			if valid(fb) {
+				return fb
+			}
+		}
+	}
+	return ``
+}
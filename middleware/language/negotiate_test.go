@@ -0,0 +1,69 @@
+package language
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	test "github.com/webx-top/echo/testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	r := ParseAcceptLanguage(`fr;q=0.5, en-US , zh_CN;q=0.9, de;q=0, ja;Q=0.7`)
+	assert.Equal(t, []AcceptLanguage{
+		{Range: `en-us`, Quality: 1},
+		{Range: `zh-cn`, Quality: 0.9},
+		{Range: `ja`, Quality: 0.7},
+		{Range: `fr`, Quality: 0.5},
+	}, r)
+	assert.Equal(t, []string{`en-us`, `zh-cn`}, ParseHeader(`fr;q=0.5, en-US , zh_CN;q=0.9`, 2))
+	assert.Equal(t, []string{}, ParseHeader(``, 5))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, `zh-hant`, Truncate(`zh-hant-cn`))
+	assert.Equal(t, `zh`, Truncate(`zh-hant`))
+	assert.Equal(t, ``, Truncate(`zh`))
+	assert.Equal(t, `de-ch`, Truncate(`de-ch-x-phonebk`))
+}
+
+func TestMatchBasicAndFilter(t *testing.T) {
+	assert.True(t, MatchBasic(`de-de`, `de-DE-1996`))
+	assert.False(t, MatchBasic(`de-de`, `de-Deva`))
+	assert.True(t, MatchBasic(`*`, `fr`))
+	assert.Equal(t, []string{`zh-cn`, `zh-tw`, `en`}, Filter([]string{`zh`, `en`}, []string{`en`, `zh-cn`, `zh-tw`}))
+}
+
+func TestNegotiate(t *testing.T) {
+	a := New()
+	a.Set(`zh-cn`, true, true)
+	a.Set(`zh-tw`, true)
+	a.Set(`en`, true)
+	a.Set(`fr`, false)
+	a.Fallbacks = map[string][]string{
+		`zh-hk`: {`zh-tw`, `zh`},
+	}
+	assert.Equal(t, `zh-tw`, a.Negotiate(`zh-hk`))
+	assert.Equal(t, `en`, a.Negotiate(`en-gb`))
+	assert.Equal(t, `zh-cn`, a.Negotiate(`zh`))
+	assert.Equal(t, ``, a.Negotiate(`fr`, `de`))
+	assert.Equal(t, `en`, a.Match(`EN_us`))
+
+	req, _ := test.NewRequestAndResponse(http.MethodGet, `/`)
+	req.Header().Set(`Accept-Language`, `fr;q=1, de;q=0.9, en-AU;q=0.5, zh-HK;q=0.8`)
+	assert.Equal(t, `zh-tw`, a.DetectHeader(req))
+	req.Header().Set(`Accept-Language`, `fr, de`)
+	assert.Equal(t, `zh-cn`, a.DetectHeader(req))
+
+	req, _ = test.NewRequestAndResponse(http.MethodGet, `/zh-HK/about`)
+	assert.Equal(t, `zh-tw`, a.DetectURI(req))
+	assert.Equal(t, `/about`, req.URL().Path())
+	req, _ = test.NewRequestAndResponse(http.MethodGet, `/about`)
+	assert.Equal(t, ``, a.DetectURI(req))
+	assert.Equal(t, `/about`, req.URL().Path())
+	for _, path := range []string{`/en-route/x`, `/en-gb/x`, `/zh-cn-x/y`} {
+		req, _ = test.NewRequestAndResponse(http.MethodGet, path)
+		assert.Equal(t, ``, a.DetectURI(req))
+		assert.Equal(t, path, req.URL().Path())
+	}
+}
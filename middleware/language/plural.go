@@ -0,0 +1,312 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package language
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CLDR 复数类别
+const (
+	PluralZero  = `zero`
+	PluralOne   = `one`
+	PluralTwo   = `two`
+	PluralFew   = `few`
+	PluralMany  = `many`
+	PluralOther = `other`
+)
+
+// PluralOperands CLDR 复数规则中使用的操作数
+// (https://unicode.org/reports/tr35/tr35-numbers.html#Operands)
+type PluralOperands struct {
+	N float64 // 绝对值
+	I int64   // 整数部分
+	V int     // 可见小数位数(含末尾的0)
+	W int     // 可见小数位数(不含末尾的0)
+	F int64   // 可见小数部分(含末尾的0)
+	T int64   // 可见小数部分(不含末尾的0)
+}
+
+// NewPluralOperands 从数字的字符串形式中解析操作数，例如“1.50”的 V 为 2
+func NewPluralOperands(number string) (PluralOperands, error) {
+	var op PluralOperands
+	number = strings.TrimPrefix(strings.TrimSpace(number), `-`)
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return op, err
+	}
+	op.N = n
+	intPart, fracPart, _ := strings.Cut(number, `.`)
+	if op.I, err = strconv.ParseInt(intPart, 10, 64); err != nil {
+		op.I = int64(n)
+	}
+	if len(fracPart) > 0 {
+		op.V = len(fracPart)
+		op.F, _ = strconv.ParseInt(fracPart, 10, 64)
+		trimmed := strings.TrimRight(fracPart, `0`)
+		op.W = len(trimmed)
+		if len(trimmed) > 0 {
+			op.T, _ = strconv.ParseInt(trimmed, 10, 64)
+		}
+	}
+	return op, nil
+}
+
+func (o PluralOperands) isInt() bool {
+	return o.V == 0
+}
+
+func (o PluralOperands) nMod(m int64) int64 {
+	if o.F != 0 {
+		return -1
+	}
+	return o.I % m
+}
+
+func inRange(v, from, to int64) bool {
+	return v >= from && v <= to
+}
+
+// PluralRule 根据操作数返回复数类别
+type PluralRule func(PluralOperands) string
+
+var (
+	pluralMutex    sync.RWMutex
+	cardinalRules  = map[string]PluralRule{}
+	ordinalRules   = map[string]PluralRule{}
+	pluralRuleOnly = func(PluralOperands) string { return PluralOther }
+)
+
+// RegisterPluralRule 为语种注册基数(cardinal)和序数(ordinal)复数规则，传入 nil 表示不修改
+func RegisterPluralRule(cardinal PluralRule, ordinal PluralRule, langs ...string) {
+	pluralMutex.Lock()
+	for _, lang := range langs {
+		lang = NormalizeTag(lang)
+		if cardinal != nil {
+			cardinalRules[lang] = cardinal
+		}
+		if ordinal != nil {
+			ordinalRules[lang] = ordinal
+		}
+	}
+	pluralMutex.Unlock()
+}
+
+func findPluralRule(rules map[string]PluralRule, lang string) PluralRule {
+	pluralMutex.RLock()
+	defer pluralMutex.RUnlock()
+	for lang = NormalizeTag(lang); len(lang) > 0; lang = Truncate(lang) {
+		if rule, ok := rules[lang]; ok {
+			return rule
+		}
+	}
+	return pluralRuleOnly
+}
+
+// PluralCategory 返回 number 在 lang 中对应的基数复数类别
+func PluralCategory(lang string, op PluralOperands) string {
+	return findPluralRule(cardinalRules, lang)(op)
+}
+
+// OrdinalCategory 返回 number 在 lang 中对应的序数复数类别
+func OrdinalCategory(lang string, op PluralOperands) string {
+	return findPluralRule(ordinalRules, lang)(op)
+}
+
+func init() {
+	RegisterPluralRule(pluralRuleOnly, nil,
+		`zh`, `ja`, `ko`, `th`, `vi`, `id`, `ms`, `lo`, `my`, `km`, `bo`, `yue`)
+	// one: i = 1 and v = 0
+	RegisterPluralRule(func(o PluralOperands) string {
+		if o.I == 1 && o.isInt() {
+			return PluralOne
+		}
+		return PluralOther
+	}, nil, `en`, `de`, `nl`, `sv`, `it`, `ca`, `et`, `fi`, `gl`, `sw`, `ur`)
+	// one: n = 1
+	RegisterPluralRule(func(o PluralOperands) string {
+		if o.N == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}, nil, `es`, `el`, `hu`, `tr`, `bg`, `nb`, `no`, `da`, `az`, `ka`, `kk`, `uz`)
+	// one: i = 0,1
+	RegisterPluralRule(func(o PluralOperands) string {
+		if o.I == 0 || o.I == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}, nil, `fr`, `pt`, `hy`, `hi`, `bn`, `fa`)
+	RegisterPluralRule(nil, func(o PluralOperands) string {
+		if o.N == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}, `fr`)
+	RegisterPluralRule(nil, func(o PluralOperands) string {
+		switch {
+		case o.nMod(10) == 1 && o.nMod(100) != 11:
+			return PluralOne
+		case o.nMod(10) == 2 && o.nMod(100) != 12:
+			return PluralTwo
+		case o.nMod(10) == 3 && o.nMod(100) != 13:
+			return PluralFew
+		}
+		return PluralOther
+	}, `en`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		if !o.isInt() {
+			return PluralOther
+		}
+		i10, i100 := o.I%10, o.I%100
+		switch {
+		case i10 == 1 && i100 != 11:
+			return PluralOne
+		case inRange(i10, 2, 4) && !inRange(i100, 12, 14):
+			return PluralFew
+		}
+		return PluralMany
+	}, nil, `ru`, `uk`, `be`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		if !o.isInt() {
+			return PluralOther
+		}
+		i10, i100 := o.I%10, o.I%100
+		switch {
+		case o.I == 1:
+			return PluralOne
+		case inRange(i10, 2, 4) && !inRange(i100, 12, 14):
+			return PluralFew
+		}
+		return PluralMany
+	}, nil, `pl`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		switch {
+		case !o.isInt():
+			return PluralMany
+		case o.I == 1:
+			return PluralOne
+		case inRange(o.I, 2, 4):
+			return PluralFew
+		}
+		return PluralOther
+	}, nil, `cs`, `sk`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		i10, i100 := o.I%10, o.I%100
+		f10, f100 := o.F%10, o.F%100
+		switch {
+		case o.isInt() && i10 == 1 && i100 != 11, f10 == 1 && f100 != 11:
+			return PluralOne
+		case o.isInt() && inRange(i10, 2, 4) && !inRange(i100, 12, 14),
+			inRange(f10, 2, 4) && !inRange(f100, 12, 14):
+			return PluralFew
+		}
+		return PluralOther
+	}, nil, `hr`, `sr`, `bs`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		n100 := o.nMod(100)
+		switch {
+		case o.N == 0:
+			return PluralZero
+		case o.N == 1:
+			return PluralOne
+		case o.N == 2:
+			return PluralTwo
+		case inRange(n100, 3, 10):
+			return PluralFew
+		case inRange(n100, 11, 99):
+			return PluralMany
+		}
+		return PluralOther
+	}, nil, `ar`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		switch {
+		case o.I == 1 && o.isInt(), o.I == 0 && !o.isInt():
+			return PluralOne
+		case o.I == 2 && o.isInt():
+			return PluralTwo
+		}
+		return PluralOther
+	}, nil, `he`, `iw`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		switch {
+		case o.I == 1 && o.isInt():
+			return PluralOne
+		case !o.isInt(), o.N == 0, inRange(o.nMod(100), 2, 19):
+			return PluralFew
+		}
+		return PluralOther
+	}, nil, `ro`, `mo`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		n10, n100 := o.nMod(10), o.nMod(100)
+		switch {
+		case n10 == 1 && !inRange(n100, 11, 19):
+			return PluralOne
+		case inRange(n10, 2, 9) && !inRange(n100, 11, 19):
+			return PluralFew
+		case o.F != 0:
+			return PluralMany
+		}
+		return PluralOther
+	}, nil, `lt`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		i100 := o.I % 100
+		switch {
+		case !o.isInt():
+			return PluralFew
+		case i100 == 1:
+			return PluralOne
+		case i100 == 2:
+			return PluralTwo
+		case inRange(i100, 3, 4):
+			return PluralFew
+		}
+		return PluralOther
+	}, nil, `sl`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		switch o.N {
+		case 0:
+			return PluralZero
+		case 1:
+			return PluralOne
+		case 2:
+			return PluralTwo
+		case 3:
+			return PluralFew
+		case 6:
+			return PluralMany
+		}
+		return PluralOther
+	}, nil, `cy`)
+	RegisterPluralRule(func(o PluralOperands) string {
+		switch {
+		case o.N == 1:
+			return PluralOne
+		case o.N == 2:
+			return PluralTwo
+		case o.F == 0 && inRange(o.I, 3, 6):
+			return PluralFew
+		case o.F == 0 && inRange(o.I, 7, 10):
+			return PluralMany
+		}
+		return PluralOther
+	}, nil, `ga`)
+}
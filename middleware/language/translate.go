@@ -44,6 +44,11 @@ func (t *Translate) T(format string, args ...interface{}) string {
 	return t.i18nObject.T(t.code.String(), format, args...)
 }
 
+// Format 使用 ICU MessageFormat 语法翻译并格式化消息
+func (t *Translate) Format(key string, args map[string]interface{}) string {
+	return t.i18nObject.Format(t.code.String(), key, args)
+}
+
 func (t *Translate) E(format string, args ...interface{}) error {
 	return errors.New(t.T(format, args...))
 }
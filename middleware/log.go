@@ -1,16 +1,21 @@
 package middleware
 
 import (
+	"crypto/tls"
 	"io"
 	std "log"
-	"strconv"
+	"math/rand"
+	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
 )
 
 type VisitorInfo struct {
+	RequestID    string
 	RealIP       string
 	Time         time.Time
 	Elapsed      time.Duration
@@ -23,11 +28,18 @@ type VisitorInfo struct {
 	RequestSize  int64
 	ResponseSize int64
 	ResponseCode int
+	Protocol     string
+	RouteName    string
+	RoutePath    string
+	UserID       string
+	TLSVersion   string
+	Upstream     string
 }
 
 var emptyTime = time.Time{}
 
 func (v *VisitorInfo) reset() {
+	v.RequestID = ``
 	v.RealIP = ``
 	v.Time = emptyTime
 	v.Elapsed = 0
@@ -40,11 +52,18 @@ func (v *VisitorInfo) reset() {
 	v.RequestSize = 0
 	v.ResponseSize = 0
 	v.ResponseCode = 0
+	v.Protocol = ``
+	v.RouteName = ``
+	v.RoutePath = ``
+	v.UserID = ``
+	v.TLSVersion = ``
+	v.Upstream = ``
 }
 
 func (v *VisitorInfo) SetFromContext(c echo.Context) {
 	req := c.Request()
 	res := c.Response()
+	v.RequestID = echo.RequestIDOf(c)
 	v.RealIP = c.RealIP()
 	v.UserAgent = req.UserAgent()
 	v.Referer = req.Referer()
@@ -56,6 +75,13 @@ func (v *VisitorInfo) SetFromContext(c echo.Context) {
 	v.URI = req.URI()
 	v.ResponseSize = res.Size()
 	v.ResponseCode = res.Status()
+	v.Protocol = req.Proto()
+	route := c.Route()
+	v.RouteName = route.Name
+	v.RoutePath = route.Path
+	if state := engine.TLSConnectionState(req); state != nil {
+		v.TLSVersion = tls.VersionName(state.Version)
+	}
 }
 
 var DefaultLogWriter = GetDefaultLogWriter()
@@ -91,9 +117,43 @@ func LogWithWriter(writer io.Writer, recv ...func(*VisitorInfo)) echo.Middleware
 
 type LogConfig struct {
 	// Skipper defines a function to skip middleware.
-	Skipper echo.Skipper       `json:"-"`
-	Writer  io.Writer          `json:"-"`
+	Skipper echo.Skipper `json:"-"`
+	Writer  io.Writer    `json:"-"`
+
+	// Execute is called with the collected info of every request instead of writing
+	// it to Writer.
 	Execute func(*VisitorInfo) `json:"-"`
+
+	// Format is the format of the log lines written to Writer.
+	// Optional. Default value LogFormatDefault.
+	// Possible values:
+	// - LogFormatDefault
+	// - LogFormatCommon: the Common Log Format
+	// - LogFormatCombined: the Combined Log Format
+	// - LogFormatJSON
+	// - LogFormatLogfmt
+	// - a template with the placeholders of the fields, e.g. "${remote_ip} ${method} ${uri} ${status}".
+	//   See `VisitorInfo.Fields()` for the names of the fields.
+	Format string `json:"format"`
+
+	// UserID returns the ID of the current user for the `user_id` field.
+	// Optional.
+	UserID func(echo.Context) string `json:"-"`
+
+	// UpstreamKey is the context key of the target selected by the Proxy middleware
+	// for the `upstream` field.
+	// Optional. Default value `DefaultProxyConfig.ContextKey`.
+	UpstreamKey string `json:"upstream_key"`
+
+	// SampleRate is the ratio of the requests to log, between 0 and 1.
+	// The requests whose response status is 500 or above are always logged.
+	// Optional. Default value 0, which logs all the requests.
+	SampleRate float64 `json:"sample_rate"`
+
+	// ExcludePaths defines the paths not to log. A path ending with "*" is a prefix,
+	// e.g. "/static/*".
+	// Optional.
+	ExcludePaths []string `json:"exclude_paths"`
 }
 
 func LogWithConfig(config LogConfig) echo.MiddlewareFunc {
@@ -103,15 +163,45 @@ func LogWithConfig(config LogConfig) echo.MiddlewareFunc {
 	if config.Writer == nil {
 		config.Writer = DefaultLogWriter
 	}
+	if len(config.UpstreamKey) == 0 {
+		config.UpstreamKey = DefaultProxyConfig.ContextKey
+	}
 	if config.Execute == nil {
 		logger := std.New(config.Writer, ``, 0)
+		format := NewLogFormatter(config.Format)
 		config.Execute = func(v *VisitorInfo) {
-			logger.Println(":" + strconv.Itoa(v.ResponseCode) + ": " + v.Time.Format(time.RFC3339) + " " + v.RealIP + " " + v.Method + " " + v.Scheme + " " + v.Host + " " + v.URI + " " + v.Elapsed.String() + " " + strconv.FormatInt(v.ResponseSize, 10))
+			logger.Println(string(format(v)))
 		}
 	}
+	excludes := make(map[string]struct{})
+	var excludePrefixes []string
+	for _, path := range config.ExcludePaths {
+		if strings.HasSuffix(path, `*`) {
+			excludePrefixes = append(excludePrefixes, strings.TrimSuffix(path, `*`))
+			continue
+		}
+		excludes[path] = struct{}{}
+	}
+	excluded := func(path string) bool {
+		if _, ok := excludes[path]; ok {
+			return true
+		}
+		for _, prefix := range excludePrefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	sampled := func(status int) bool {
+		if config.SampleRate <= 0 || config.SampleRate >= 1 || status >= http.StatusInternalServerError {
+			return true
+		}
+		return rand.Float64() < config.SampleRate
+	}
 	return func(h echo.Handler) echo.Handler {
 		return echo.HandlerFunc(func(c echo.Context) error {
-			if config.Skipper(c) {
+			if config.Skipper(c) || excluded(c.Request().URL().Path()) {
 				return h.Handle(c)
 			}
 			info := AcquireVisitorInfo()
@@ -119,10 +209,28 @@ func LogWithConfig(config LogConfig) echo.MiddlewareFunc {
 			if err := h.Handle(c); err != nil {
 				c.Error(err)
 			}
-			info.SetFromContext(c)
-			config.Execute(info)
+			if sampled(c.Response().Status()) {
+				info.SetFromContext(c)
+				if config.UserID != nil {
+					info.UserID = config.UserID(c)
+				}
+				info.Upstream = upstreamOf(c, config.UpstreamKey)
+				config.Execute(info)
+			}
 			ReleaseVisitorInfo(info)
 			return nil
 		})
 	}
 }
+
+func upstreamOf(c echo.Context, key string) string {
+	switch v := c.Get(key).(type) {
+	case ProxyTargeter:
+		if u := v.GetURL(c); u != nil {
+			return u.String()
+		}
+	case string:
+		return v
+	}
+	return ``
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of the Log middleware.
+const (
+	LogFormatDefault  = ``
+	LogFormatCommon   = `common`
+	LogFormatCombined = `combined`
+	LogFormatJSON     = `json`
+	LogFormatLogfmt   = `logfmt`
+)
+
+// LogField is a named field of the access log.
+type LogField struct {
+	Name  string
+	Value interface{}
+}
+
+// Fields returns the fields of the access log in order. The optional fields
+// (request_id, route_name, referer, user_id, tls_version and upstream) are
+// omitted when empty.
+func (v *VisitorInfo) Fields() []LogField {
+	fields := make([]LogField, 0, 21)
+	add := func(name string, value interface{}, optional ...bool) {
+		if len(optional) > 0 && optional[0] && value == `` {
+			return
+		}
+		fields = append(fields, LogField{Name: name, Value: value})
+	}
+	add(`time`, v.Time.Format(time.RFC3339))
+	add(`request_id`, v.RequestID, true)
+	add(`remote_ip`, v.RealIP)
+	add(`method`, v.Method)
+	add(`scheme`, v.Scheme)
+	add(`host`, v.Host)
+	add(`uri`, v.URI)
+	add(`protocol`, v.Protocol)
+	add(`route`, v.RoutePath)
+	add(`route_name`, v.RouteName, true)
+	add(`status`, v.ResponseCode)
+	add(`latency`, v.Elapsed.Nanoseconds())
+	add(`latency_human`, v.Elapsed.String())
+	add(`bytes_in`, v.RequestSize)
+	add(`bytes_out`, v.ResponseSize)
+	add(`referer`, v.Referer, true)
+	add(`user_agent`, v.UserAgent)
+	add(`user_id`, v.UserID, true)
+	add(`tls_version`, v.TLSVersion, true)
+	add(`upstream`, v.Upstream, true)
+	return fields
+}
+
+// NewLogFormatter returns the function formatting the log line of the format.
+// See `LogConfig.Format`.
+func NewLogFormatter(format string) func(*VisitorInfo) []byte {
+	switch format {
+	case LogFormatDefault:
+		return formatLogDefault
+	case LogFormatCommon:
+		return func(v *VisitorInfo) []byte {
+			return []byte(commonLogLine(v))
+		}
+	case LogFormatCombined:
+		return func(v *VisitorInfo) []byte {
+			return []byte(commonLogLine(v) + ` "` + escapeLogItem(v.Referer) + `" "` + escapeLogItem(v.UserAgent) + `"`)
+		}
+	case LogFormatJSON:
+		return formatLogJSON
+	case LogFormatLogfmt:
+		return formatLogfmt
+	default:
+		return newLogTemplate(format)
+	}
+}
+
+func formatLogDefault(v *VisitorInfo) []byte {
+	line := ":" + strconv.Itoa(v.ResponseCode) + ": " + v.Time.Format(time.RFC3339) + " " + v.RealIP + " " + v.Method + " " + v.Scheme + " " + v.Host + " " + v.URI + " " + v.Elapsed.String() + " " + strconv.FormatInt(v.ResponseSize, 10)
+	if len(v.RequestID) > 0 {
+		line += " " + v.RequestID
+	}
+	return []byte(line)
+}
+
+// commonLogLine returns the line of the Common Log Format:
+// host ident authuser [date] "request" status bytes
+func commonLogLine(v *VisitorInfo) string {
+	user := escapeLogItem(v.UserID)
+	if len(user) == 0 {
+		user = `-`
+	}
+	size := `-`
+	if v.ResponseSize > 0 {
+		size = strconv.FormatInt(v.ResponseSize, 10)
+	}
+	return v.RealIP + ` - ` + user + ` [` + v.Time.Format(`02/Jan/2006:15:04:05 -0700`) + `] "` +
+		escapeLogItem(v.Method) + ` ` + escapeLogItem(v.URI) + ` ` + escapeLogItem(v.Protocol) + `" ` + strconv.Itoa(v.ResponseCode) + ` ` + size
+}
+
+// escapeLogItem escapes the value like Apache: `"` and `\` are escaped with a backslash,
+// the control characters and the non-ASCII bytes are written as `\xHH`,
+// so that a request can not forge the fields or the lines of the log.
+func escapeLogItem(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			break
+		}
+	}
+	if i == len(s) {
+		return s
+	}
+	const hex = `0123456789abcdef`
+	b := make([]byte, i, len(s)+8)
+	copy(b, s[:i])
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20 || c >= 0x7f:
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+func formatLogJSON(v *VisitorInfo) []byte {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, f := range v.Fields() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(f.Name))
+		b.WriteByte(':')
+		value, _ := json.Marshal(f.Value)
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return []byte(b.String())
+}
+
+func formatLogfmt(v *VisitorInfo) []byte {
+	var b strings.Builder
+	for i, f := range v.Fields() {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.Name)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(logFieldString(f.Value)))
+	}
+	return []byte(b.String())
+}
+
+func logfmtValue(value string) string {
+	if len(value) == 0 || strings.ContainsAny(value, " =\"\t\r\n\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func logFieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ``
+}
+
+// newLogTemplate returns the formatter of the template with the `${name}` placeholders.
+// The unknown or omitted fields are replaced with "-".
+func newLogTemplate(format string) func(*VisitorInfo) []byte {
+	var (
+		texts []string
+		names []string
+	)
+	for {
+		start := strings.Index(format, `${`)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(format[start:], `}`)
+		if end < 0 {
+			break
+		}
+		texts = append(texts, format[:start])
+		names = append(names, format[start+2:start+end])
+		format = format[start+end+1:]
+	}
+	texts = append(texts, format)
+	return func(v *VisitorInfo) []byte {
+		values := make(map[string]string, 21)
+		for _, f := range v.Fields() {
+			values[f.Name] = logFieldString(f.Value)
+		}
+		var b strings.Builder
+		for i, name := range names {
+			b.WriteString(texts[i])
+			if value, ok := values[name]; ok {
+				b.WriteString(value)
+			} else {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteString(texts[len(texts)-1])
+		return []byte(b.String())
+	}
+}
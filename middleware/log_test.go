@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine/standard"
+	test "github.com/webx-top/echo/testing"
+)
+
+func newTestLogEcho(config LogConfig) *echo.Echo {
+	e := echo.New()
+	e.Use(RequestID(), LogWithConfig(config))
+	e.Get(`/users/:id`, func(c echo.Context) error {
+		c.Set(`target`, `http://10.0.0.1:8080`)
+		return c.String(`OK`)
+	}).SetName(`user.detail`)
+	e.Get(`/static/app.js`, func(c echo.Context) error {
+		return c.String(`js`)
+	})
+	e.Get(`/fail`, func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusServiceUnavailable)
+	})
+	e.Commit()
+	return e
+}
+
+func TestLogFormats(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := newTestLogEcho(LogConfig{
+		Writer: buf,
+		Format: LogFormatJSON,
+		UserID: func(c echo.Context) string {
+			return `u1`
+		},
+		ExcludePaths: []string{`/static/*`, `/healthz`},
+	})
+	client := test.NewClient(e)
+	client.Get(`/users/7`).Header(echo.HeaderXRequestID, `rid-1`).Header(`Referer`, `https://example.com/`).Do()
+	client.Get(`/static/app.js`).Do()
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, `rid-1`, entry[`request_id`])
+	assert.Equal(t, `/users/:id`, entry[`route`])
+	assert.Equal(t, `user.detail`, entry[`route_name`])
+	assert.Equal(t, float64(200), entry[`status`])
+	assert.Equal(t, `u1`, entry[`user_id`])
+	assert.Equal(t, `http://10.0.0.1:8080`, entry[`upstream`])
+	assert.Equal(t, `https://example.com/`, entry[`referer`])
+	assert.Equal(t, `/users/7`, entry[`uri`])
+	assert.NotContains(t, entry, `tls_version`)
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	e = newTestLogEcho(LogConfig{Writer: buf, Format: LogFormatCombined})
+	test.NewClient(e).Get(`/users/7`).Header(`User-Agent`, `UA "1"`).Do()
+	assert.Regexp(t, `^\S+ - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /users/7 HTTP/1.1" 200 2 "" "UA \\"1\\""\n$`, buf.String())
+
+	buf.Reset()
+	e = newTestLogEcho(LogConfig{Writer: buf, Format: LogFormatLogfmt})
+	test.NewClient(e).Get(`/users/7`).Header(echo.HeaderXRequestID, `rid-2`).Header(`User-Agent`, `Go test`).Do()
+	assert.Contains(t, buf.String(), ` request_id=rid-2 `)
+	assert.Contains(t, buf.String(), ` route=/users/:id route_name=user.detail status=200 `)
+	assert.Contains(t, buf.String(), ` user_agent="Go test" `)
+
+	buf.Reset()
+	e = newTestLogEcho(LogConfig{Writer: buf, Format: `${method} ${route} ${status} ${request_id} ${unknown}`})
+	test.NewClient(e).Get(`/users/7`).Header(echo.HeaderXRequestID, `rid-3`).Do()
+	assert.Equal(t, "GET /users/:id 200 rid-3 -\n", buf.String())
+}
+
+func TestCommonLogLineEscape(t *testing.T) {
+	v := &VisitorInfo{
+		RealIP:       `127.0.0.1`,
+		UserID:       "a b\"c",
+		Method:       `GET`,
+		URI:          "/x\" 200 1 \"\n127.0.0.2 - - \xff",
+		Protocol:     `HTTP/1.1`,
+		ResponseCode: 200,
+	}
+	line := commonLogLine(v)
+	assert.NotContains(t, line, "\n")
+	assert.Contains(t, line, ` - a b\"c [`)
+	assert.Contains(t, line, `"GET /x\" 200 1 \"\x0a127.0.0.2 - - \xff HTTP/1.1" 200 -`)
+	assert.Equal(t, `\\ \x7f`, escapeLogItem("\\ \x7f"))
+}
+
+func TestLogSampling(t *testing.T) {
+	var logged []int
+	e := newTestLogEcho(LogConfig{
+		SampleRate: 0.000001,
+		Execute: func(v *VisitorInfo) {
+			logged = append(logged, v.ResponseCode)
+		},
+	})
+	client := test.NewClient(e)
+	for i := 0; i < 10; i++ {
+		client.Get(`/users/7`).Do()
+	}
+	client.Get(`/fail`).Do()
+	assert.Equal(t, []int{http.StatusServiceUnavailable}, logged)
+}
+
+func TestLogWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `access.log`)
+	file, err := OpenLogFile(path)
+	assert.NoError(t, err)
+	w := NewAsyncLogWriter(file, 10)
+	w.Write([]byte("line1\n"))
+	assert.NoError(t, w.Close())
+	_, err = w.Write([]byte("line2\n"))
+	assert.Equal(t, os.ErrClosed, err)
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, "line1\n", string(b))
+
+	// rotation
+	file, err = OpenLogFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Rename(path, path+`.1`))
+	file.Write([]byte("old\n"))
+	assert.NoError(t, file.Reopen())
+	file.Write([]byte("new\n"))
+	assert.NoError(t, file.Close())
+	b, _ = os.ReadFile(path + `.1`)
+	assert.Equal(t, "line1\nold\n", string(b))
+	b, _ = os.ReadFile(path)
+	assert.Equal(t, "new\n", string(b))
+}
+
+func TestLogTLSVersion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := newTestLogEcho(LogConfig{Writer: buf, Format: LogFormatJSON})
+	s := standard.New(``)
+	s.SetHandler(e)
+	ts := httptest.NewTLSServer(s)
+	defer ts.Close()
+	resp, err := ts.Client().Get(ts.URL + `/users/7`)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, `TLS 1.3`, entry[`tls_version`])
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncLogWriter writes the logs to the underlying writer in a goroutine, so that
+// the requests are not blocked by a slow disk. The logs are dropped when the
+// buffer is full.
+type AsyncLogWriter struct {
+	writer  io.Writer
+	queue   chan []byte
+	done    chan struct{}
+	dropped uint64
+	once    sync.Once
+	mutex   sync.RWMutex
+	closed  bool
+}
+
+// NewAsyncLogWriter returns an AsyncLogWriter buffering up to bufferSize log lines.
+func NewAsyncLogWriter(w io.Writer, bufferSize int) *AsyncLogWriter {
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	a := &AsyncLogWriter{
+		writer: w,
+		queue:  make(chan []byte, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncLogWriter) run() {
+	for p := range a.queue {
+		a.writer.Write(p)
+	}
+	close(a.done)
+}
+
+func (a *AsyncLogWriter) Write(p []byte) (int, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if a.closed {
+		return 0, os.ErrClosed
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	select {
+	case a.queue <- b:
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of the logs dropped because the buffer was full.
+func (a *AsyncLogWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Close writes the buffered logs and closes the underlying writer if it is an io.Closer.
+func (a *AsyncLogWriter) Close() error {
+	var err error
+	a.once.Do(func() {
+		a.mutex.Lock()
+		a.closed = true
+		close(a.queue)
+		a.mutex.Unlock()
+		<-a.done
+		if closer, ok := a.writer.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
+}
+
+// LogFile is a log file which can be reopened after it is moved by a log rotation tool.
+type LogFile struct {
+	path  string
+	file  *os.File
+	mutex sync.Mutex
+}
+
+// OpenLogFile opens the file for appending, creating it if it does not exist.
+func OpenLogFile(path string) (*LogFile, error) {
+	f := &LogFile{path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *LogFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	return f.file.Write(p)
+}
+
+// Reopen closes the file and opens the path again.
+func (f *LogFile) Reopen() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	old := f.file
+	f.file = file
+	f.mutex.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (f *LogFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// ReopenOnSignal reopens the file when the process receives one of the signals,
+// `DefaultReopenSignals` (SIGUSR1) by default, e.g. from logrotate:
+//
+//	postrotate
+//		kill -USR1 $(cat /run/app.pid)
+//	endscript
+//
+// It returns a function to stop.
+func ReopenOnSignal(f *LogFile, onError func(error), signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = DefaultReopenSignals
+	}
+	if len(signals) == 0 {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := f.Reopen(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
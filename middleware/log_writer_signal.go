@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package middleware
+
+import (
+	"os"
+	"syscall"
+)
+
+// DefaultReopenSignals are the signals watched by `ReopenOnSignal`.
+// SIGHUP is not used, which restarts the server (see `engine.DefaultRestartSignals`).
+var DefaultReopenSignals = []os.Signal{syscall.SIGUSR1}
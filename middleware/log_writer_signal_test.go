@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo/engine"
+)
+
+func TestReopenOnSignal(t *testing.T) {
+	for _, sig := range engine.DefaultRestartSignals {
+		assert.NotContains(t, DefaultReopenSignals, sig)
+	}
+
+	path := filepath.Join(t.TempDir(), `access.log`)
+	file, err := OpenLogFile(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	stop := ReopenOnSignal(file, func(err error) { t.Error(err) })
+	defer stop()
+
+	assert.NoError(t, os.Rename(path, path+`.1`))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package middleware
+
+import "os"
+
+// DefaultReopenSignals are the signals watched by `ReopenOnSignal`.
+var DefaultReopenSignals []os.Signal
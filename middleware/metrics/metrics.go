@@ -0,0 +1,209 @@
+package metrics
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware"
+)
+
+const (
+	// UnmatchedRoute is the route label of the requests which match no route.
+	UnmatchedRoute = `unmatched`
+
+	// OtherMethod is the method label of the requests with a non-standard method,
+	// so that the number of time series stays bounded.
+	OtherMethod = `OTHER`
+)
+
+type (
+	// Config defines the config for Metrics middleware.
+	Config struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper `json:"-"`
+
+		// Namespace is the prefix of the metric names.
+		// Optional. Default value "echo".
+		Namespace string
+
+		// Subsystem is the second part of the metric names.
+		// Optional.
+		Subsystem string
+
+		// Registry where the metrics are registered.
+		// Optional. Default value `DefaultRegistry`.
+		Registry *Registry `json:"-"`
+
+		// Buckets of the request duration in seconds.
+		// Optional. Default value `DefaultBuckets`.
+		Buckets []float64
+
+		// SizeBuckets of the request and response sizes in bytes.
+		// Optional. Default value `DefaultSizeBuckets`.
+		SizeBuckets []float64
+
+		// UseRouteName uses `Route.Name` instead of `Route.Path` as the route label
+		// when the route has a name.
+		UseRouteName bool
+
+		// RouteLabel returns the route label.
+		// Optional. Default uses the registered route instead of the request URI
+		// so that the number of time series stays bounded.
+		RouteLabel func(echo.Context) string `json:"-"`
+	}
+
+	// Metrics holds the metrics recorded by the middleware.
+	Metrics struct {
+		config           Config
+		RequestsTotal    *CounterVec
+		RequestDuration  *HistogramVec
+		RequestSize      *HistogramVec
+		ResponseSize     *HistogramVec
+		RequestsInFlight *GaugeVec
+	}
+)
+
+var (
+	// DefaultConfig is the default Metrics middleware config.
+	DefaultConfig = Config{
+		Skipper:   echo.DefaultSkipper,
+		Namespace: `echo`,
+	}
+)
+
+// New creates the metrics and registers them in the registry of the config.
+func New(config Config) *Metrics {
+	if config.Skipper == nil {
+		config.Skipper = DefaultConfig.Skipper
+	}
+	if len(config.Namespace) == 0 {
+		config.Namespace = DefaultConfig.Namespace
+	}
+	if config.Registry == nil {
+		config.Registry = DefaultRegistry
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultBuckets
+	}
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+	if config.RouteLabel == nil {
+		config.RouteLabel = config.routeLabel
+	}
+	labels := []string{`method`, `route`, `code`}
+	m := &Metrics{
+		config:           config,
+		RequestsTotal:    NewCounterVec(config.name(`requests_total`), `Total number of HTTP requests.`, labels...),
+		RequestDuration:  NewHistogramVec(config.name(`request_duration_seconds`), `HTTP request latencies in seconds.`, config.Buckets, labels...),
+		RequestSize:      NewHistogramVec(config.name(`request_size_bytes`), `HTTP request sizes in bytes.`, config.SizeBuckets, labels...),
+		ResponseSize:     NewHistogramVec(config.name(`response_size_bytes`), `HTTP response sizes in bytes.`, config.SizeBuckets, labels...),
+		RequestsInFlight: NewGaugeVec(config.name(`requests_in_flight`), `Number of HTTP requests being served.`, `method`),
+	}
+	config.Registry.MustRegister(m.RequestsTotal, m.RequestDuration, m.RequestSize, m.ResponseSize, m.RequestsInFlight)
+	return m
+}
+
+func (config *Config) name(name string) string {
+	if len(config.Subsystem) > 0 {
+		name = config.Subsystem + `_` + name
+	}
+	return config.Namespace + `_` + name
+}
+
+func (config *Config) routeLabel(c echo.Context) string {
+	route := c.Route()
+	if config.UseRouteName && len(route.Name) > 0 {
+		return route.Name
+	}
+	if len(route.Path) == 0 {
+		return UnmatchedRoute
+	}
+	return route.Path
+}
+
+// methodLabel returns the method label, which is OtherMethod for a non-standard method.
+func methodLabel(method string) string {
+	switch method {
+	case echo.CONNECT, echo.DELETE, echo.GET, echo.HEAD, echo.OPTIONS, echo.PATCH, echo.POST, echo.PUT, echo.TRACE:
+		return method
+	}
+	return OtherMethod
+}
+
+// Middleware returns a middleware which records the metrics of the requests.
+// Like `middleware.Log`, the error of the handler is handled by `c.Error()`
+// in the middleware so that the final status code is recorded.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	config := m.config
+	return func(h echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return h.Handle(c)
+			}
+			info := middleware.AcquireVisitorInfo()
+			info.Time = time.Now()
+			method := methodLabel(c.Request().Method())
+			m.RequestsInFlight.Inc(method)
+			defer m.RequestsInFlight.Dec(method)
+			if err := h.Handle(c); err != nil {
+				c.Error(err)
+			}
+			info.SetFromContext(c)
+			m.Observe(c, info)
+			middleware.ReleaseVisitorInfo(info)
+			return nil
+		})
+	}
+}
+
+// Observe records a finished request.
+func (m *Metrics) Observe(c echo.Context, info *middleware.VisitorInfo) {
+	values := []string{methodLabel(info.Method), m.config.RouteLabel(c), strconv.Itoa(info.ResponseCode)}
+	m.RequestsTotal.Inc(values...)
+	m.RequestDuration.Observe(info.Elapsed.Seconds(), values...)
+	if info.RequestSize >= 0 {
+		m.RequestSize.Observe(float64(info.RequestSize), values...)
+	}
+	m.ResponseSize.Observe(float64(info.ResponseSize), values...)
+}
+
+var (
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
+)
+
+// Default returns the metrics created with the default config. They are created
+// and registered in `DefaultRegistry` once, on the first call.
+func Default() *Metrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = New(DefaultConfig)
+	})
+	return defaultMetrics
+}
+
+// Middleware returns a middleware recording the metrics with the default config.
+// All the returned middlewares share the metrics of `Default()`.
+func Middleware() echo.MiddlewareFunc {
+	return Default().Middleware()
+}
+
+// Handler returns a handler serving the metrics of the registry (default `DefaultRegistry`)
+// in the text exposition format, e.g. `e.Get("/metrics", metrics.Handler())`.
+func Handler(registry ...*Registry) echo.HandlerFunc {
+	r := DefaultRegistry
+	if len(registry) > 0 && registry[0] != nil {
+		r = registry[0]
+	}
+	return func(c echo.Context) error {
+		buf := new(bytes.Buffer)
+		if err := r.WriteText(buf); err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderContentType, ContentType)
+		return c.Blob(buf.Bytes())
+	}
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	te "github.com/webx-top/echo/testing"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := NewRegistry()
+	m := New(Config{Registry: registry, Buckets: []float64{0.1, 1}, SizeBuckets: []float64{10}})
+	e := echo.New()
+	e.Use(m.Middleware())
+	e.Get(`/users/:id`, func(c echo.Context) error {
+		return c.String(`user ` + c.Param(`id`))
+	})
+	e.Get(`/fail`, func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+	e.Get(`/metrics`, Handler(registry))
+	e.Commit()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(te.WrapRequest(req), te.WrapResponse(req, rec))
+		return rec
+	}
+	serve(`/users/1`)
+	serve(`/users/2`)
+	serve(`/fail`)
+	serve(`/none`)
+	assert.Equal(t, float64(2), m.RequestsTotal.Value(`GET`, `/users/:id`, `200`))
+	assert.Equal(t, float64(1), m.RequestsTotal.Value(`GET`, `/fail`, `403`))
+	assert.Equal(t, float64(1), m.RequestsTotal.Value(`GET`, UnmatchedRoute, `404`))
+	assert.Equal(t, uint64(2), m.RequestDuration.Count(`GET`, `/users/:id`, `200`))
+
+	// the non-standard methods share a label
+	for _, method := range []string{`FOO`, `BAR`} {
+		req := httptest.NewRequest(method, `/none`, nil)
+		e.ServeHTTP(te.WrapRequest(req), te.WrapResponse(req, httptest.NewRecorder()))
+	}
+	assert.Equal(t, float64(2), m.RequestsTotal.Value(OtherMethod, UnmatchedRoute, `404`))
+	assert.Equal(t, float64(0), m.RequestsTotal.Value(`FOO`, UnmatchedRoute, `404`))
+
+	rec := serve(`/metrics`)
+	assert.Equal(t, ContentType, rec.Header().Get(echo.HeaderContentType))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`# TYPE echo_requests_total counter`,
+		`echo_requests_total{method="GET",route="/users/:id",code="200"} 2`,
+		`# TYPE echo_request_duration_seconds histogram`,
+		`echo_request_duration_seconds_bucket{method="GET",route="/users/:id",code="200",le="+Inf"} 2`,
+		`echo_request_duration_seconds_count{method="GET",route="/users/:id",code="200"} 2`,
+		`echo_response_size_bytes_bucket{method="GET",route="/users/:id",code="200",le="10"} 2`,
+		`echo_requests_in_flight{method="GET"} 1`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.NotContains(t, body, `/users/1`)
+	assert.Panics(t, func() { New(Config{Registry: registry}) })
+
+	// the in-flight gauge is decremented when the handler panics
+	e.Get(`/panic`, func(c echo.Context) error {
+		panic(`boom`)
+	})
+	e.Commit()
+	assert.Panics(t, func() { serve(`/panic`) })
+	assert.Equal(t, float64(0), m.RequestsInFlight.Value(`GET`))
+}
+
+func TestDefaultMiddleware(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Middleware()
+		Middleware()
+	})
+	assert.Same(t, Default(), Default())
+}
+
+func TestRegistryWriteText(t *testing.T) {
+	registry := NewRegistry()
+	h := NewHistogramVec(`latency`, "a\nb", []float64{1, 0.5})
+	h.Observe(0.5)
+	h.Observe(0.7)
+	h.Observe(3)
+	g := NewGaugeVec(`temp`, ``, `room`)
+	g.Set(1.5, `a"b`)
+	g.Dec(`a"b`)
+	registry.MustRegister(h, g, NewGaugeFunc(`conns`, `Open connections.`, func() float64 { return 3 }))
+	buf := new(strings.Builder)
+	assert.NoError(t, registry.WriteText(buf))
+	assert.Equal(t, `# HELP conns Open connections.
+# TYPE conns gauge
+conns 3
+# HELP latency a\nb
+# TYPE latency histogram
+latency_bucket{le="0.5"} 1
+latency_bucket{le="1"} 2
+latency_bucket{le="+Inf"} 3
+latency_sum 4.2
+latency_count 3
+# TYPE temp gauge
+temp{room="a\"b"} 0.5
+`, buf.String())
+}
@@ -0,0 +1,358 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = `text/plain; version=0.0.4; charset=utf-8`
+
+var (
+	// DefaultBuckets are the buckets of the request duration in seconds.
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the buckets of the request and response sizes in bytes.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Collector is a metric family which can be written in the text exposition format.
+type Collector interface {
+	Name() string
+	WriteText(w *bufio.Writer)
+}
+
+// Registry is a set of collectors.
+type Registry struct {
+	mutex      sync.RWMutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]Collector{}}
+}
+
+// DefaultRegistry is the registry used by default.
+var DefaultRegistry = NewRegistry()
+
+// Register adds the collector. It returns an error if a collector with the same name exists.
+func (r *Registry) Register(c Collector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.collectors[c.Name()]; ok {
+		return fmt.Errorf(`metrics: duplicate collector %q`, c.Name())
+	}
+	r.collectors[c.Name()] = c
+	return nil
+}
+
+// MustRegister adds the collectors and panics on error.
+func (r *Registry) MustRegister(collectors ...Collector) {
+	for _, c := range collectors {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *Registry) Unregister(name string) {
+	r.mutex.Lock()
+	delete(r.collectors, name)
+	r.mutex.Unlock()
+}
+
+// Get returns the collector named name, or nil.
+func (r *Registry) Get(name string) Collector {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.collectors[name]
+}
+
+// WriteText writes all the metrics sorted by name in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mutex.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	collectors := make([]Collector, len(names))
+	for i, name := range names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mutex.RUnlock()
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.WriteText(bw)
+	}
+	return bw.Flush()
+}
+
+// family is the name, help and labels shared by the metric types.
+type family struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (f *family) Name() string {
+	return f.name
+}
+
+func (f *family) writeHeader(w *bufio.Writer, typ string) {
+	if len(f.help) > 0 {
+		w.WriteString(`# HELP ` + f.name + ` ` + strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help) + "\n")
+	}
+	w.WriteString(`# TYPE ` + f.name + ` ` + typ + "\n")
+}
+
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf(`metrics: %s expects %d label values, got %d`, f.name, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSample writes `name{labels,extra} value`.
+func writeSample(w *bufio.Writer, name string, labels []string, values []string, extraLabel string, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || len(extraLabel) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label + `="` + labelValueEscaper.Replace(values[i]) + `"`)
+		}
+		if len(extraLabel) > 0 {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraLabel + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return `+Inf`
+	case math.IsInf(v, -1):
+		return `-Inf`
+	case math.IsNaN(v):
+		return `NaN`
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type sample struct {
+	values []string
+	value  float64
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	family
+	mutex   sync.Mutex
+	samples map[string]*sample
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{family: family{name: name, help: help, labels: labels}, samples: map[string]*sample{}}
+}
+
+// Add adds v (>= 0) to the counter of the label values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(`metrics: counter can not decrease`)
+	}
+	key := c.key(values)
+	c.mutex.Lock()
+	s, ok := c.samples[key]
+	if !ok {
+		s = &sample{values: append([]string(nil), values...)}
+		c.samples[key] = s
+	}
+	s.value += v
+	c.mutex.Unlock()
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the counter of the label values.
+func (c *CounterVec) Value(values ...string) float64 {
+	key := c.key(values)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if s, ok := c.samples[key]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (c *CounterVec) WriteText(w *bufio.Writer) {
+	c.writeHeader(w, `counter`)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, key := range sortedKeys(c.samples) {
+		s := c.samples[key]
+		writeSample(w, c.name, c.labels, s.values, ``, ``, s.value)
+	}
+}
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct {
+	CounterVec
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{CounterVec: CounterVec{family: family{name: name, help: help, labels: labels}, samples: map[string]*sample{}}}
+}
+
+// Add adds v, which may be negative, to the gauge of the label values.
+func (g *GaugeVec) Add(v float64, values ...string) {
+	key := g.key(values)
+	g.mutex.Lock()
+	s, ok := g.samples[key]
+	if !ok {
+		s = &sample{values: append([]string(nil), values...)}
+		g.samples[key] = s
+	}
+	s.value += v
+	g.mutex.Unlock()
+}
+
+func (g *GaugeVec) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+func (g *GaugeVec) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+func (g *GaugeVec) Set(v float64, values ...string) {
+	key := g.key(values)
+	g.mutex.Lock()
+	s, ok := g.samples[key]
+	if !ok {
+		s = &sample{values: append([]string(nil), values...)}
+		g.samples[key] = s
+	}
+	s.value = v
+	g.mutex.Unlock()
+}
+
+func (g *GaugeVec) WriteText(w *bufio.Writer) {
+	g.writeHeader(w, `gauge`)
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	for _, key := range sortedKeys(g.samples) {
+		s := g.samples[key]
+		writeSample(w, g.name, g.labels, s.values, ``, ``, s.value)
+	}
+}
+
+// GaugeFunc is a gauge without labels whose value is read when it is collected,
+// e.g. from `engine.StatsProvider.Stats()`.
+type GaugeFunc struct {
+	family
+	fn func() float64
+}
+
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	return &GaugeFunc{family: family{name: name, help: help}, fn: fn}
+}
+
+func (g *GaugeFunc) WriteText(w *bufio.Writer) {
+	g.writeHeader(w, `gauge`)
+	writeSample(w, g.name, nil, nil, ``, ``, g.fn())
+}
+
+type histogramSample struct {
+	values []string
+	counts []uint64 // not cumulative
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	family
+	buckets []float64
+	mutex   sync.Mutex
+	samples map[string]*histogramSample
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{family: family{name: name, help: help, labels: labels}, buckets: buckets, samples: map[string]*histogramSample{}}
+}
+
+// Observe adds an observation to the histogram of the label values.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	i := sort.SearchFloat64s(h.buckets, v) // the first bucket whose upper bound >= v
+	h.mutex.Lock()
+	s, ok := h.samples[key]
+	if !ok {
+		s = &histogramSample{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.samples[key] = s
+	}
+	if i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+	h.mutex.Unlock()
+}
+
+// Count returns the number of observations of the label values.
+func (h *HistogramVec) Count(values ...string) uint64 {
+	key := h.key(values)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if s, ok := h.samples[key]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) WriteText(w *bufio.Writer) {
+	h.writeHeader(w, `histogram`)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, key := range sortedKeys(h.samples) {
+		s := h.samples[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+`_bucket`, h.labels, s.values, `le`, formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.name+`_bucket`, h.labels, s.values, `le`, `+Inf`, float64(s.count))
+		writeSample(w, h.name+`_sum`, h.labels, s.values, ``, ``, s.sum)
+		writeSample(w, h.name+`_count`, h.labels, s.values, ``, ``, float64(s.count))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
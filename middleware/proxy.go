@@ -123,6 +123,9 @@ var (
 		if h, ok := DefaultProxyHandlers[key]; ok {
 			resp := c.Response().StdResponseWriter()
 			req := c.Request().StdRequest()
+			if rid := echo.RequestIDOf(c); len(rid) > 0 && len(req.Header.Get(echo.HeaderXRequestID)) == 0 {
+				req.Header.Set(echo.HeaderXRequestID, rid)
+			}
 			h(t, c).ServeHTTP(resp, req)
 		}
 		return nil
@@ -142,16 +145,34 @@ var (
 	}
 )
 
+const proxyTransportKey = `proxy.transport`
+
+// SetProxyTransport sets the transport used by the default proxy handlers to send the
+// request of c to the upstream, e.g. a transport starting client spans set by a tracing
+// middleware. Wrap `ProxyTransport(c)` to keep the transport set by the previous middlewares.
+func SetProxyTransport(c echo.Context, rt http.RoundTripper) {
+	c.Internal().Set(proxyTransportKey, rt)
+}
+
+// ProxyTransport returns the transport set by `SetProxyTransport`, or nil for `http.DefaultTransport`.
+func ProxyTransport(c echo.Context) http.RoundTripper {
+	rt, _ := c.Internal().Get(proxyTransportKey).(http.RoundTripper)
+	return rt
+}
+
 // Server-Sent Events
 func proxyHTTPWithFlushInterval(t ProxyTargeter, c echo.Context) http.Handler {
 	proxy := httputil.NewSingleHostReverseProxy(t.GetURL(c))
 	proxy.FlushInterval = t.GetFlushInterval()
+	proxy.Transport = ProxyTransport(c)
 	return proxy
 }
 
 // http
 func proxyHTTP(t ProxyTargeter, c echo.Context) http.Handler {
-	return httputil.NewSingleHostReverseProxy(t.GetURL(c))
+	proxy := httputil.NewSingleHostReverseProxy(t.GetURL(c))
+	proxy.Transport = ProxyTransport(c)
+	return proxy
 }
 
 // ProxyHTTPCustomHandler 自定义处理(支持传递body)
@@ -161,7 +182,7 @@ func ProxyHTTPCustomHandler(t ProxyTargeter, c echo.Context) http.Handler {
 
 func newSingleHostReverseProxy(target *url.URL, c echo.Context) *httputil.ReverseProxy {
 	director := DefaultProxyHTTPDirector(target, c)
-	return &httputil.ReverseProxy{Director: director}
+	return &httputil.ReverseProxy{Director: director, Transport: ProxyTransport(c)}
 }
 
 // DefaultProxyHTTPDirector default director
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"strings"
+	"time"
+
 	"github.com/webx-top/echo"
 )
 
@@ -22,6 +25,30 @@ type (
 		// DisablePrintStack disables printing stack trace.
 		// Optional. Default value as false.
 		DisablePrintStack bool `json:"disable_print_stack"`
+
+		// Reporters are called asynchronously with the report of every panic,
+		// e.g. NewWebhookPanicReporter, NewWriterPanicReporter or NewEventPanicReporter.
+		// Optional.
+		Reporters []PanicReporter `json:"-"`
+
+		// ReportInterval is the minimum interval between the reports of the panics
+		// with the same stack fingerprint. The identical panics in the interval are
+		// counted in `PanicReport.Suppressed` of the next report.
+		// Optional. Default value 1 minute.
+		ReportInterval time.Duration `json:"report_interval"`
+
+		// ReportTimeout is the timeout of calling all the reporters for a panic.
+		// Optional. Default value 10 seconds.
+		ReportTimeout time.Duration `json:"report_timeout"`
+
+		// OnReportError is called with the errors returned by the reporters.
+		// Optional. The errors are logged by the logger of Echo by default.
+		OnReportError func(error) `json:"-"`
+
+		// DisableDebugPage disables the HTML page of the panic which is shown to the
+		// browsers in debug mode.
+		// Optional. Default value false.
+		DisableDebugPage bool `json:"disable_debug_page"`
 	}
 )
 
@@ -32,6 +59,8 @@ var (
 		StackSize:         4 << 10, // 4 KB
 		DisableStackAll:   false,
 		DisablePrintStack: false,
+		ReportInterval:    time.Minute,
+		ReportTimeout:     10 * time.Second,
 	}
 )
 
@@ -51,6 +80,16 @@ func RecoverWithConfig(config RecoverConfig) echo.MiddlewareFunc {
 	if config.StackSize == 0 {
 		config.StackSize = DefaultRecoverConfig.StackSize
 	}
+	if config.ReportInterval == 0 {
+		config.ReportInterval = DefaultRecoverConfig.ReportInterval
+	}
+	if config.ReportTimeout == 0 {
+		config.ReportTimeout = DefaultRecoverConfig.ReportTimeout
+	}
+	var dispatcher *panicDispatcher
+	if len(config.Reporters) > 0 {
+		dispatcher = newPanicDispatcher(&config)
+	}
 
 	return func(next echo.Handler) echo.Handler {
 		return echo.HandlerFunc(func(c echo.Context) error {
@@ -61,7 +100,17 @@ func RecoverWithConfig(config RecoverConfig) echo.MiddlewareFunc {
 			defer func() {
 				if r := recover(); r != nil {
 					panicErr := echo.NewPanicError(r, nil, c.Echo().Debug(), config.DisableStackAll).Parse(config.StackSize)
+					panicErr.SetRequestID(echo.RequestIDOf(c))
 					c.Logger().Error(panicErr)
+					if dispatcher != nil {
+						dispatcher.dispatch(c, panicErr)
+					}
+					if !config.DisableDebugPage && c.Echo().Debug() && !c.Response().Committed() &&
+						strings.Contains(c.Header(echo.HeaderAccept), echo.MIMETextHTML) {
+						if err := RenderPanicPage(c, panicErr); err == nil {
+							return
+						}
+					}
 					c.Error(panicErr)
 				}
 			}()
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/webx-top/echo"
+)
+
+// SessionValuer is implemented by the sessions which can list their values,
+// which are shown on the debug page of the Recover middleware.
+type SessionValuer interface {
+	Values() map[string]interface{}
+}
+
+// PanicPageMaskedNames are the substrings of the names of the headers, form fields and
+// session values whose values are masked on the debug page.
+var PanicPageMaskedNames = []string{`authorization`, `cookie`, `password`, `secret`, `token`}
+
+type panicPageItem struct {
+	Name  string
+	Value string
+}
+
+type panicPageData struct {
+	Error     template.HTML
+	Message   string
+	RequestID string
+	Method    string
+	URI       string
+	Route     string
+	Headers   []panicPageItem
+	Params    []panicPageItem
+	Form      []panicPageItem
+	Session   []panicPageItem
+}
+
+var panicPageTemplate = template.Must(template.New(`panic`).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Panic: {{.Message}}</title>
+<style>
+body{font-family:sans-serif;margin:20px;color:#333}
+h1{color:#c00;font-size:22px;word-break:break-all}
+h2{font-size:17px;margin-top:24px}
+table{border-collapse:collapse;width:100%;margin-bottom:10px;font-size:13px}
+th,td{border:1px solid #ddd;padding:4px 8px;text-align:left;vertical-align:top;word-break:break-all}
+th{background:#f5f5f5}
+pre{background:#f5f5f5;padding:10px;overflow:auto}
+</style>
+</head>
+<body>
+<h1>{{.Message}}</h1>
+<p><code>{{.Method}} {{.URI}}</code>{{if .Route}} &rarr; <code>{{.Route}}</code>{{end}}{{if .RequestID}} &middot; Request ID: <code>{{.RequestID}}</code>{{end}}</p>
+{{.Error}}
+{{define "items"}}{{if .}}<table><tbody>{{range .}}<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>{{end}}</tbody></table>{{else}}<p>None</p>{{end}}{{end}}
+<h2>Route Parameters</h2>
+{{template "items" .Params}}
+<h2>Form</h2>
+{{template "items" .Form}}
+<h2>Session</h2>
+{{template "items" .Session}}
+<h2>Request Headers</h2>
+{{template "items" .Headers}}
+</body>
+</html>`))
+
+// RenderPanicPage renders the debug page of the panic, which shows the stack and snippets
+// of `PanicError.HTML()` with the request headers, route parameters, form and session values.
+// It should only be used in debug mode.
+func RenderPanicPage(c echo.Context, panicErr *echo.PanicError) error {
+	req := c.Request()
+	data := &panicPageData{
+		Error:     panicErr.HTML(),
+		Message:   fmt.Sprint(panicErr.Raw),
+		RequestID: echo.RequestIDOf(c),
+		Method:    req.Method(),
+		URI:       req.URI(),
+		Route:     c.Route().Path,
+	}
+	header := req.Header().Std()
+	for name, values := range header {
+		data.Headers = append(data.Headers, panicPageItem{Name: name, Value: maskPanicPageValue(name, strings.Join(values, `, `))})
+	}
+	for i, name := range c.ParamNames() {
+		data.Params = append(data.Params, panicPageItem{Name: name, Value: c.P(i)})
+	}
+	for name, values := range c.Forms() {
+		data.Form = append(data.Form, panicPageItem{Name: name, Value: maskPanicPageValue(name, strings.Join(values, `, `))})
+	}
+	for name, value := range panicPageSessionValues(c) {
+		data.Session = append(data.Session, panicPageItem{Name: name, Value: maskPanicPageValue(name, fmt.Sprintf(`%+v`, value))})
+	}
+	sortPanicPageItems(data.Headers, data.Form, data.Session)
+
+	buf := &bytes.Buffer{}
+	if err := panicPageTemplate.Execute(buf, data); err != nil {
+		return err
+	}
+	return c.HTML(buf.String(), http.StatusInternalServerError)
+}
+
+// panicPageSessionValues returns the session values, or nil if the session can not be read.
+func panicPageSessionValues(c echo.Context) (values map[string]interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			values = nil
+		}
+	}()
+	if sv, ok := c.Session().(SessionValuer); ok {
+		values = sv.Values()
+	}
+	return
+}
+
+func maskPanicPageValue(name string, value string) string {
+	name = strings.ToLower(name)
+	for _, masked := range PanicPageMaskedNames {
+		if strings.Contains(name, masked) {
+			return `******`
+		}
+	}
+	return value
+}
+
+func sortPanicPageItems(lists ...[]panicPageItem) {
+	for _, items := range lists {
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/admpub/events"
+	"github.com/webx-top/echo"
+)
+
+// PanicEventName is the default name of the event fired by NewEventPanicReporter.
+const PanicEventName = `echo.panic`
+
+const echoServeHTTPFunc = `github.com/webx-top/echo.(*Echo).ServeHTTP`
+
+type (
+	// PanicReport is the report of a recovered panic.
+	PanicReport struct {
+		Fingerprint string    `json:"fingerprint"`
+		Time        time.Time `json:"time"`
+		Error       string    `json:"error"`
+		Stack       []string  `json:"stack,omitempty"`
+		RequestID   string    `json:"request_id,omitempty"`
+		Method      string    `json:"method"`
+		URI         string    `json:"uri"`
+		Host        string    `json:"host"`
+		Route       string    `json:"route,omitempty"`
+		RealIP      string    `json:"remote_ip"`
+		UserAgent   string    `json:"user_agent,omitempty"`
+
+		// Suppressed is the number of the identical panics not reported since the last report.
+		Suppressed int `json:"suppressed,omitempty"`
+	}
+
+	// PanicReporter reports the panics, e.g. to a webhook, a file or an event bus.
+	PanicReporter interface {
+		ReportPanic(context.Context, *PanicReport) error
+	}
+
+	// PanicReporterFunc is an adapter to allow the use of ordinary functions as PanicReporter.
+	PanicReporterFunc func(context.Context, *PanicReport) error
+)
+
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, report *PanicReport) error {
+	return f(ctx, report)
+}
+
+// NewPanicReport returns the report of the panic in the request.
+func NewPanicReport(c echo.Context, panicErr *echo.PanicError) *PanicReport {
+	req := c.Request()
+	report := &PanicReport{
+		Time:      time.Now(),
+		Error:     fmt.Sprint(panicErr.Raw),
+		RequestID: echo.RequestIDOf(c),
+		Method:    req.Method(),
+		URI:       req.URI(),
+		Host:      req.Host(),
+		Route:     c.Route().Path,
+		RealIP:    c.RealIP(),
+		UserAgent: req.UserAgent(),
+		Stack:     make([]string, len(panicErr.Traces)),
+	}
+	for i, trace := range panicErr.Traces {
+		report.Stack[i] = trace.Func + ` ` + trace.File + `:` + strconv.Itoa(trace.Line)
+	}
+	report.Fingerprint = PanicFingerprint(panicErr)
+	return report
+}
+
+// PanicFingerprint returns the fingerprint of the stack of the panic, which is
+// the same for the panics at the same place even if their messages are different.
+// The frames below `Echo.ServeHTTP` are ignored, and the message is used if there
+// is no stack.
+func PanicFingerprint(panicErr *echo.PanicError) string {
+	h := sha256.New()
+	if len(panicErr.Traces) == 0 {
+		fmt.Fprint(h, panicErr.Raw)
+	}
+	for _, trace := range panicErr.Traces {
+		io.WriteString(h, trace.Func+`@`+trace.File+`:`+strconv.Itoa(trace.Line)+"\n")
+		if trace.Func == echoServeHTTPFunc {
+			break
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// NewWebhookPanicReporter returns a PanicReporter which posts the reports as JSON to the URL.
+// http.DefaultClient is used if client is nil.
+func NewWebhookPanicReporter(url string, client *http.Client) PanicReporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return PanicReporterFunc(func(ctx context.Context, report *PanicReport) error {
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf(`panic webhook %s: unexpected status %d`, url, resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// NewWriterPanicReporter returns a PanicReporter which writes the reports as JSON lines
+// to the writer, e.g. a LogFile.
+func NewWriterPanicReporter(w io.Writer) PanicReporter {
+	var mutex sync.Mutex
+	return PanicReporterFunc(func(_ context.Context, report *PanicReport) error {
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		_, err = w.Write(append(b, '\n'))
+		return err
+	})
+}
+
+// NewEventPanicReporter returns a PanicReporter which fires the event with the report
+// as the `report` data. The name is PanicEventName if empty.
+func NewEventPanicReporter(name string) PanicReporter {
+	if len(name) == 0 {
+		name = PanicEventName
+	}
+	return PanicReporterFunc(func(_ context.Context, report *PanicReport) error {
+		return echo.FireByNameWithMap(name, events.Map{`report`: report})
+	})
+}
+
+type panicState struct {
+	reported   time.Time
+	suppressed int
+}
+
+// panicDispatcher calls the reporters asynchronously and limits the reports of
+// the identical panics.
+type panicDispatcher struct {
+	config *RecoverConfig
+	states map[string]*panicState
+	mutex  sync.Mutex
+}
+
+func newPanicDispatcher(config *RecoverConfig) *panicDispatcher {
+	return &panicDispatcher{
+		config: config,
+		states: make(map[string]*panicState),
+	}
+}
+
+// allow reports whether the panic with the fingerprint should be reported, and the
+// number of the suppressed panics since the last report.
+func (d *panicDispatcher) allow(fingerprint string, now time.Time) (bool, int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	state, ok := d.states[fingerprint]
+	if ok && now.Sub(state.reported) < d.config.ReportInterval {
+		state.suppressed++
+		return false, 0
+	}
+	if !ok {
+		if len(d.states) >= 1000 {
+			for key, s := range d.states {
+				if now.Sub(s.reported) >= d.config.ReportInterval {
+					delete(d.states, key)
+				}
+			}
+		}
+		state = &panicState{}
+		d.states[fingerprint] = state
+	}
+	suppressed := state.suppressed
+	state.reported = now
+	state.suppressed = 0
+	return true, suppressed
+}
+
+func (d *panicDispatcher) dispatch(c echo.Context, panicErr *echo.PanicError) {
+	report := NewPanicReport(c, panicErr)
+	ok, suppressed := d.allow(report.Fingerprint, report.Time)
+	if !ok {
+		return
+	}
+	report.Suppressed = suppressed
+	onError := d.config.OnReportError
+	if onError == nil {
+		logger := c.Logger()
+		onError = func(err error) {
+			logger.Error(`failed to report panic: `, err)
+		}
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), d.config.ReportTimeout)
+		defer cancel()
+		for _, reporter := range d.config.Reporters {
+			if err := reporter.ReportPanic(ctx, report); err != nil {
+				onError(err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+func receivePanicReport(t *testing.T, ch chan *PanicReport) *PanicReport {
+	select {
+	case report := <-ch:
+		return report
+	case <-time.After(5 * time.Second):
+		t.Fatal(`timeout waiting for the panic report`)
+	}
+	return nil
+}
+
+func TestRecoverReporters(t *testing.T) {
+	webhook := make(chan *PanicReport, 10)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := &PanicReport{}
+		json.NewDecoder(r.Body).Decode(report)
+		webhook <- report
+	}))
+	defer backend.Close()
+
+	reported := make(chan *PanicReport, 10)
+	e := echo.New()
+	e.Use(RequestID(), RecoverWithConfig(RecoverConfig{
+		Reporters: []PanicReporter{
+			NewWebhookPanicReporter(backend.URL, nil),
+			PanicReporterFunc(func(_ context.Context, report *PanicReport) error {
+				reported <- report
+				return nil
+			}),
+		},
+		ReportInterval: 200 * time.Millisecond,
+	}))
+	e.Get(`/users/:id`, func(c echo.Context) error {
+		panic(`boom ` + c.Param(`id`))
+	})
+	e.Commit()
+	client := test.NewClient(e)
+
+	client.Get(`/users/1`).Header(echo.HeaderXRequestID, `rid-1`).Do().Expect(t).Status(http.StatusInternalServerError)
+	report := receivePanicReport(t, reported)
+	assert.Equal(t, `boom 1`, report.Error)
+	assert.Equal(t, `rid-1`, report.RequestID)
+	assert.Equal(t, `/users/:id`, report.Route)
+	assert.Equal(t, `/users/1`, report.URI)
+	assert.NotEmpty(t, report.Stack)
+	assert.Len(t, report.Fingerprint, 16)
+	assert.Equal(t, report.Fingerprint, receivePanicReport(t, webhook).Fingerprint)
+
+	// identical panics are suppressed in the interval
+	client.Get(`/users/2`).Do()
+	client.Get(`/users/3`).Do()
+	time.Sleep(250 * time.Millisecond)
+	client.Get(`/users/4`).Do()
+	report = receivePanicReport(t, reported)
+	assert.Equal(t, `boom 4`, report.Error)
+	assert.Equal(t, 2, report.Suppressed)
+	assert.Len(t, reported, 0)
+}
+
+func TestRecoverEventReporter(t *testing.T) {
+	reported := make(chan *PanicReport, 1)
+	echo.OnCallback(`test.panic`, func(e echo.Event) error {
+		reported <- e.Context.Get(`report`).(*PanicReport)
+		return nil
+	})
+	defer echo.Off(`test.panic`)
+	e := echo.New()
+	e.Use(RecoverWithConfig(RecoverConfig{
+		Reporters: []PanicReporter{NewEventPanicReporter(`test.panic`)},
+	}))
+	e.Get(`/`, func(c echo.Context) error {
+		panic(`event`)
+	})
+	e.Commit()
+	test.NewClient(e).Get(`/`).Do()
+	assert.Equal(t, `event`, receivePanicReport(t, reported).Error)
+}
+
+func TestRecoverDebugPage(t *testing.T) {
+	e := echo.New()
+	e.SetDebug(true)
+	e.Use(RequestID(), Recover())
+	e.Post(`/users/:id`, func(c echo.Context) error {
+		panic(`debug <page>`)
+	})
+	e.Commit()
+	client := test.NewClient(e)
+
+	resp := client.Post(`/users/7`).
+		Header(echo.HeaderAccept, `text/html,application/xhtml+xml`).
+		Header(echo.HeaderAuthorization, `Bearer secret-value`).
+		Header(echo.HeaderXRequestID, `rid-page`).
+		FormValue(`name`, `Tom`).
+		FormValue(`password`, `123456`).
+		Do()
+	resp.Expect(t).
+		Status(http.StatusInternalServerError).
+		HeaderContains(echo.HeaderContentType, echo.MIMETextHTML).
+		BodyContains(`<h1>debug &lt;page&gt;</h1>`).
+		BodyContains(`Request ID: <code>rid-page</code>`).
+		BodyContains(`<tr><th>id</th><td>7</td></tr>`).
+		BodyContains(`<tr><th>name</th><td>Tom</td></tr>`).
+		BodyContains(`<tr><th>password</th><td>******</td></tr>`).
+		BodyContains(`<tr><th>Authorization</th><td>******</td></tr>`).
+		BodyContains(`panic-table-trace`)
+	assert.NotContains(t, resp.String(), `secret-value`)
+
+	// not for API clients
+	resp = client.Post(`/users/7`).Header(echo.HeaderAccept, echo.MIMEApplicationJSON).Do()
+	resp.Expect(t).Status(http.StatusInternalServerError)
+	assert.NotContains(t, resp.String(), `<html>`)
+}
+
+func TestRecoverDebugPageEscape(t *testing.T) {
+	e := echo.New()
+	e.SetDebug(true)
+	e.Use(RecoverWithConfig(RecoverConfig{DisableStackAll: true}))
+	e.Get(`/`, func(c echo.Context) error {
+		panic(`bad input ` + c.Query(`x`))
+	})
+	e.Commit()
+
+	resp := test.NewClient(e).Get(`/?x=` + url.QueryEscape(`<script>alert(1)</script>`)).
+		Header(echo.HeaderAccept, echo.MIMETextHTML).
+		Do()
+	resp.Expect(t).
+		Status(http.StatusInternalServerError).
+		BodyContains(`bad input &lt;script&gt;alert(1)&lt;/script&gt;`)
+	assert.NotContains(t, resp.String(), `<script>`)
+}
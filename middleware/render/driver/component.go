@@ -0,0 +1,580 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ComponentTag        = `Component`
+	ComponentSlotTag    = `Slot`
+	ComponentFillTag    = `Fill`
+	ComponentPropsTag   = `Props`
+	ComponentPropsFunc  = `ComponentProps` // 模板函数名，用于将键值对参数组合为 map
+	DefaultSlot         = `default`
+	DefaultComponentDir = `components`
+)
+
+var (
+	ErrComponentNotFound  = errors.New(`component not found`)
+	ErrComponentSyntax    = errors.New(`component syntax error`)
+	ErrComponentSlot      = errors.New(`component slot error`)
+	ErrComponentProps     = errors.New(`component props error`)
+	ErrComponentRecursive = errors.New(`component is recursive`)
+)
+
+// ComponentSupported 支持组件的模板引擎
+type ComponentSupported interface {
+	Components() *Components
+}
+
+// ComponentSyntax 组件展开时使用的模板方言
+type ComponentSyntax interface {
+	// Delims 返回模板标签的左右定界符
+	Delims() (left string, right string)
+	// BlockKeywords 返回需要 {{end}} 结束的模板关键词(例如 if、range)
+	BlockKeywords() []string
+	// Scope 将组件内容包裹在独立的作用域中，并将 props 表达式绑定到组件内的 props 变量
+	Scope(props string, body string) string
+	// Props 根据调用组件时传入的参数生成 props 表达式
+	Props(args []string) string
+}
+
+// GoTemplateSyntax Go 模板(text/template 和 html/template)的组件方言。
+// 组件内通过 $props 访问参数
+type GoTemplateSyntax struct {
+	Left  string
+	Right string
+}
+
+func (g *GoTemplateSyntax) Delims() (string, string) {
+	return g.Left, g.Right
+}
+
+func (g *GoTemplateSyntax) BlockKeywords() []string {
+	return []string{`if`, `range`, `with`, `define`, `block`}
+}
+
+func (g *GoTemplateSyntax) Scope(props string, body string) string {
+	return g.Left + `if true` + g.Right + g.Left + `$props := ` + props + g.Right + body + g.Left + `end` + g.Right
+}
+
+func (g *GoTemplateSyntax) Props(args []string) string {
+	switch len(args) {
+	case 0:
+		return `.`
+	case 1:
+		return args[0]
+	default:
+		return `(` + ComponentPropsFunc + ` ` + strings.Join(args, ` `) + `)`
+	}
+}
+
+// ComponentPropsMap 将键值对参数组合为 map，作为模板函数 ComponentProps 使用
+func ComponentPropsMap(pairs ...interface{}) map[string]interface{} {
+	props := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		props[fmt.Sprint(pairs[i])] = pairs[i+1]
+	}
+	return props
+}
+
+// ComponentSlot 组件中的插槽定义
+type ComponentSlot struct {
+	Name     string
+	Required bool
+	Default  string
+}
+
+// Component 组件定义。组件文件的格式:
+//
+//	{{Props "title" "size?"}}
+//	<div class="card">
+//	  <h3>{{Slot "header"/}}</h3>
+//	  {{Slot "default"/}}
+//	  <footer>{{Slot "footer"}}默认内容{{/Slot}}</footer>
+//	</div>
+//
+// {{Slot "name"/}} 为必须提供的插槽，{{Slot "name"}}...{{/Slot}} 为带默认内容的可选插槽；
+// Props 中以“?”结尾的参数为可选参数
+type Component struct {
+	Name          string
+	Body          string
+	Slots         map[string]*ComponentSlot
+	Props         []string
+	RequiredProps []string
+}
+
+// Components 组件注册表。组件通过 {{Component "name" args...}}...{{end}} 调用，
+// 也可以用 {{/Component}} 结束或使用自闭合形式 {{Component "name" args.../}}。
+// 调用处通过 {{Fill "slot"}}...{{/Fill}} 填充具名插槽，其余内容填充 default 插槽
+type Components struct {
+	syntax     ComponentSyntax
+	tokenRegex *regexp.Regexp
+	tokenDelim string
+	loader     func(name string) ([]byte, error)
+	lock       sync.RWMutex
+	components map[string]*Component
+	registered map[string]*Component
+}
+
+// NewComponents 创建组件注册表。loader 用于按名称读取未注册的组件内容
+func NewComponents(syntax ComponentSyntax, loader func(name string) ([]byte, error)) *Components {
+	return &Components{
+		syntax:     syntax,
+		loader:     loader,
+		components: map[string]*Component{},
+		registered: map[string]*Component{},
+	}
+}
+
+// Register 注册组件
+func (c *Components) Register(name string, content string) error {
+	comp, err := c.Parse(name, content)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.registered[name] = comp
+	c.lock.Unlock()
+	return nil
+}
+
+// Get 获取组件定义
+func (c *Components) Get(name string) (*Component, error) {
+	c.lock.RLock()
+	comp, ok := c.registered[name]
+	if !ok {
+		comp, ok = c.components[name]
+	}
+	c.lock.RUnlock()
+	if ok {
+		return comp, nil
+	}
+	if c.loader == nil {
+		return nil, fmt.Errorf(`%w: %s`, ErrComponentNotFound, name)
+	}
+	b, err := c.loader(name)
+	if err != nil {
+		return nil, fmt.Errorf(`%w: %s: %v`, ErrComponentNotFound, name, err)
+	}
+	comp, err = c.Parse(name, string(b))
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	c.components[name] = comp
+	c.lock.Unlock()
+	return comp, nil
+}
+
+// ClearCache 清除从 loader 读取的组件缓存(已注册的组件不受影响)
+func (c *Components) ClearCache() {
+	c.lock.Lock()
+	c.components = map[string]*Component{}
+	c.lock.Unlock()
+}
+
+// Parse 解析组件定义
+func (c *Components) Parse(name string, content string) (*Component, error) {
+	comp := &Component{Name: name, Slots: map[string]*ComponentSlot{}}
+	tokens := c.tokenize(content)
+	var b strings.Builder
+	var pos int
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.keyword {
+		case ComponentPropsTag:
+			args, err := splitArgs(tok.args)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: %s: %v`, ErrComponentSyntax, name, err)
+			}
+			for _, arg := range args {
+				prop, err := unquoteArg(arg)
+				if err != nil {
+					return nil, fmt.Errorf(`%w: %s: invalid prop %s`, ErrComponentSyntax, name, arg)
+				}
+				optional := strings.HasSuffix(prop, `?`)
+				prop = strings.TrimSuffix(prop, `?`)
+				comp.Props = append(comp.Props, prop)
+				if !optional {
+					comp.RequiredProps = append(comp.RequiredProps, prop)
+				}
+			}
+			b.WriteString(content[pos:tok.start])
+			pos = tok.end
+		case ComponentSlotTag:
+			slotName, err := unquoteArg(tok.args)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: %s: invalid slot name %s`, ErrComponentSyntax, name, tok.args)
+			}
+			slot := &ComponentSlot{Name: slotName, Required: tok.selfClosing}
+			if !tok.selfClosing {
+				j, err := c.matchClose(tokens, i, ComponentSlotTag)
+				if err != nil {
+					return nil, fmt.Errorf(`%w: %s: %v`, ErrComponentSyntax, name, err)
+				}
+				slot.Default = content[tok.end:tokens[j].start]
+				b.WriteString(content[pos:tok.start])
+				pos = tokens[j].end
+				i = j
+			} else {
+				b.WriteString(content[pos:tok.start])
+				pos = tok.end
+			}
+			if _, ok := comp.Slots[slotName]; ok {
+				return nil, fmt.Errorf(`%w: %s: duplicate slot %q`, ErrComponentSyntax, name, slotName)
+			}
+			comp.Slots[slotName] = slot
+			b.WriteString(slotPlaceholder(slotName))
+		}
+	}
+	b.WriteString(content[pos:])
+	comp.Body = b.String()
+	return comp, nil
+}
+
+// Expand 展开内容中的所有组件调用，同时检查必须的插槽和参数是否已提供
+func (c *Components) Expand(content []byte) ([]byte, error) {
+	left, _ := c.syntax.Delims()
+	if !strings.Contains(string(content), left) || !strings.Contains(string(content), ComponentTag) {
+		return content, nil
+	}
+	s, err := c.expand(string(content), nil)
+	if err != nil {
+		return content, err
+	}
+	return []byte(s), nil
+}
+
+func (c *Components) expand(content string, stack []string) (string, error) {
+	tokens := c.tokenize(content)
+	var b strings.Builder
+	var pos int
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.keyword != ComponentTag {
+			continue
+		}
+		args, err := splitArgs(tok.args)
+		if err != nil || len(args) == 0 {
+			return ``, fmt.Errorf(`%w: invalid invocation %q`, ErrComponentSyntax, content[tok.start:tok.end])
+		}
+		name, err := unquoteArg(args[0])
+		if err != nil {
+			return ``, fmt.Errorf(`%w: invalid component name %s`, ErrComponentSyntax, args[0])
+		}
+		args = args[1:]
+		for _, v := range stack {
+			if v == name {
+				return ``, fmt.Errorf(`%w: %s`, ErrComponentRecursive, strings.Join(append(stack, name), ` → `))
+			}
+		}
+		b.WriteString(content[pos:tok.start])
+		var inner string
+		if tok.selfClosing {
+			pos = tok.end
+		} else {
+			j, err := c.matchClose(tokens, i, ComponentTag)
+			if err != nil {
+				return ``, fmt.Errorf(`%w: %s: %v`, ErrComponentSyntax, name, err)
+			}
+			inner = content[tok.end:tokens[j].start]
+			pos = tokens[j].end
+			i = j
+		}
+		comp, err := c.Get(name)
+		if err != nil {
+			return ``, err
+		}
+		if err = comp.checkProps(args); err != nil {
+			return ``, err
+		}
+		fills, err := c.splitFills(name, inner)
+		if err != nil {
+			return ``, err
+		}
+		for slotName, fill := range fills {
+			if fills[slotName], err = c.expand(fill, stack); err != nil {
+				return ``, err
+			}
+		}
+		body, err := comp.fillSlots(fills)
+		if err != nil {
+			return ``, err
+		}
+		body, err = c.expand(body, append(stack, name))
+		if err != nil {
+			return ``, err
+		}
+		b.WriteString(c.syntax.Scope(c.syntax.Props(args), body))
+	}
+	b.WriteString(content[pos:])
+	return b.String(), nil
+}
+
+// splitFills 将调用组件时的内容拆分为各个插槽的填充内容
+func (c *Components) splitFills(name string, inner string) (map[string]string, error) {
+	fills := map[string]string{}
+	tokens := c.tokenize(inner)
+	var rest strings.Builder
+	var pos int
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.keyword == ComponentFillTag:
+			slotName, err := unquoteArg(tok.args)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: %s: invalid fill name %s`, ErrComponentSyntax, name, tok.args)
+			}
+			if _, ok := fills[slotName]; ok {
+				return nil, fmt.Errorf(`%w: %s: duplicate fill %q`, ErrComponentSlot, name, slotName)
+			}
+			rest.WriteString(inner[pos:tok.start])
+			if tok.selfClosing {
+				fills[slotName] = ``
+				pos = tok.end
+				continue
+			}
+			j, err := c.matchClose(tokens, i, ComponentFillTag)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: %s: %v`, ErrComponentSyntax, name, err)
+			}
+			fills[slotName] = inner[tok.end:tokens[j].start]
+			pos = tokens[j].end
+			i = j
+		case tok.opens(c.syntax):
+			// 跳过嵌套的块(包括嵌套组件中的 Fill)
+			j, err := c.matchClose(tokens, i, tok.keyword)
+			if err != nil {
+				return nil, fmt.Errorf(`%w: %s: %v`, ErrComponentSyntax, name, err)
+			}
+			i = j
+		}
+	}
+	rest.WriteString(inner[pos:])
+	if _, ok := fills[DefaultSlot]; !ok {
+		if content := rest.String(); len(strings.TrimSpace(content)) > 0 {
+			fills[DefaultSlot] = content
+		}
+	}
+	return fills, nil
+}
+
+func (comp *Component) checkProps(args []string) error {
+	if len(comp.RequiredProps) == 0 || len(args) < 2 {
+		// 直接传入对象时无法在编译期检查
+		return nil
+	}
+	if len(args)%2 != 0 {
+		return fmt.Errorf(`%w: %s: odd number of key/value arguments`, ErrComponentProps, comp.Name)
+	}
+	provided := map[string]struct{}{}
+	for i := 0; i < len(args); i += 2 {
+		key, err := unquoteArg(args[i])
+		if err != nil {
+			return fmt.Errorf(`%w: %s: prop name must be a string literal: %s`, ErrComponentProps, comp.Name, args[i])
+		}
+		provided[key] = struct{}{}
+	}
+	for _, prop := range comp.RequiredProps {
+		if _, ok := provided[prop]; !ok {
+			return fmt.Errorf(`%w: %s: missing required prop %q`, ErrComponentProps, comp.Name, prop)
+		}
+	}
+	return nil
+}
+
+func (comp *Component) fillSlots(fills map[string]string) (string, error) {
+	for slotName := range fills {
+		if _, ok := comp.Slots[slotName]; !ok {
+			if slotName == DefaultSlot && len(strings.TrimSpace(fills[slotName])) == 0 {
+				continue
+			}
+			return ``, fmt.Errorf(`%w: %s: unknown slot %q`, ErrComponentSlot, comp.Name, slotName)
+		}
+	}
+	body := comp.Body
+	for slotName, slot := range comp.Slots {
+		fill, ok := fills[slotName]
+		if !ok {
+			if slot.Required {
+				return ``, fmt.Errorf(`%w: %s: missing required slot %q`, ErrComponentSlot, comp.Name, slotName)
+			}
+			fill = slot.Default
+		}
+		body = strings.Replace(body, slotPlaceholder(slotName), fill, 1)
+	}
+	return body, nil
+}
+
+func slotPlaceholder(name string) string {
+	return "\x00slot:" + name + "\x00"
+}
+
+type componentToken struct {
+	start       int
+	end         int
+	keyword     string
+	args        string
+	selfClosing bool
+	closing     bool
+}
+
+func (t *componentToken) opens(syntax ComponentSyntax) bool {
+	if t.closing || t.selfClosing {
+		return false
+	}
+	switch t.keyword {
+	case ComponentTag, ComponentFillTag, ComponentSlotTag:
+		return true
+	}
+	for _, kw := range syntax.BlockKeywords() {
+		if t.keyword == kw {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Components) tokenRegexp() *regexp.Regexp {
+	left, right := c.syntax.Delims()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.tokenRegex == nil || c.tokenDelim != left+right {
+		c.tokenRegex = regexp.MustCompile(`(?s)` + regexp.QuoteMeta(left) + `(.*?)` + regexp.QuoteMeta(right))
+		c.tokenDelim = left + right
+	}
+	return c.tokenRegex
+}
+
+func (c *Components) tokenize(content string) []*componentToken {
+	matches := c.tokenRegexp().FindAllStringSubmatchIndex(content, -1)
+	tokens := make([]*componentToken, 0, len(matches))
+	for _, m := range matches {
+		tok := &componentToken{start: m[0], end: m[1]}
+		inner := strings.TrimSpace(content[m[2]:m[3]])
+		inner = strings.TrimSpace(strings.TrimPrefix(inner, `- `))
+		inner = strings.TrimSpace(strings.TrimSuffix(inner, ` -`))
+		if strings.HasPrefix(inner, `/*`) {
+			continue
+		}
+		if strings.HasPrefix(inner, `/`) {
+			tok.closing = true
+			tok.keyword = strings.TrimSpace(inner[1:])
+			tokens = append(tokens, tok)
+			continue
+		}
+		if strings.HasSuffix(inner, `/`) {
+			tok.selfClosing = true
+			inner = strings.TrimSpace(strings.TrimSuffix(inner, `/`))
+		}
+		pos := strings.IndexAny(inner, " \t\r\n(")
+		if pos == -1 {
+			tok.keyword = inner
+		} else {
+			tok.keyword = inner[:pos]
+			tok.args = strings.TrimSpace(inner[pos:])
+		}
+		if tok.keyword == `end` {
+			tok.closing = true
+			tok.keyword = ``
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// matchClose 查找与 tokens[i] 配对的结束标签。组件、插槽和填充可以用 {{/Tag}} 或 {{end}} 结束
+func (c *Components) matchClose(tokens []*componentToken, i int, keyword string) (int, error) {
+	var stack []string
+	for j := i + 1; j < len(tokens); j++ {
+		tok := tokens[j]
+		if tok.opens(c.syntax) {
+			stack = append(stack, tok.keyword)
+			continue
+		}
+		if !tok.closing {
+			continue
+		}
+		if len(stack) == 0 {
+			if len(tok.keyword) == 0 || tok.keyword == keyword {
+				return j, nil
+			}
+			return -1, fmt.Errorf(`unexpected {{/%s}}, expecting the end of %q`, tok.keyword, keyword)
+		}
+		stack = stack[:len(stack)-1]
+	}
+	return -1, fmt.Errorf(`unclosed %q`, keyword)
+}
+
+// splitArgs 按空白拆分参数，保留引号和括号中的内容
+func splitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		quote rune
+		start = -1
+	)
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '`' || i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+			continue
+		case r == '"' || r == '`' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case (r == ' ' || r == '\t' || r == '\n' || r == '\r') && depth == 0:
+			if start >= 0 {
+				args = append(args, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return nil, errors.New(`unbalanced quotes or parentheses`)
+	}
+	if start >= 0 {
+		args = append(args, s[start:])
+	}
+	return args, nil
+}
+
+func unquoteArg(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`") && len(s) > 1 {
+		return s[1 : len(s)-1], nil
+	}
+	return strconv.Unquote(s)
+}
@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestComponents(t *testing.T) *Components {
+	c := NewComponents(&GoTemplateSyntax{Left: `{{`, Right: `}}`}, nil)
+	assert.NoError(t, c.Register(`card`, `{{Props "title" "size?"}}<div class="card {{$props.size}}"><h3>{{$props.title}}</h3>{{Slot "header"/}}<p>{{Slot "default"/}}</p><footer>{{Slot "footer"}}-{{/Slot}}</footer></div>`))
+	assert.NoError(t, c.Register(`box`, `<b>{{Slot "default"}}empty{{/Slot}}</b>`))
+	assert.NoError(t, c.Register(`loop`, `{{Component "loop"/}}`))
+	return c
+}
+
+func executeComponents(t *testing.T, c *Components, content string, data interface{}) string {
+	b, err := c.Expand([]byte(content))
+	if !assert.NoError(t, err) {
+		return ``
+	}
+	tmpl, err := template.New(`test`).Funcs(template.FuncMap{ComponentPropsFunc: ComponentPropsMap}).Parse(string(b))
+	if !assert.NoError(t, err) {
+		return ``
+	}
+	buf := new(bytes.Buffer)
+	assert.NoError(t, tmpl.Execute(buf, data))
+	return buf.String()
+}
+
+func TestComponentExpand(t *testing.T) {
+	c := newTestComponents(t)
+	data := map[string]interface{}{`Title`: `Hello`, `Items`: []int{1, 2}}
+
+	r := executeComponents(t, c, `{{Component "card" "title" .Title "size" "lg"}}{{Fill "header"}}<i>{{.Title}}</i>{{/Fill}}{{range .Items}}{{.}}{{end}}{{end}}`, data)
+	assert.Equal(t, `<div class="card lg"><h3>Hello</h3><i>Hello</i><p>12</p><footer>-</footer></div>`, r)
+
+	r = executeComponents(t, c, `{{Component "box"/}}|{{Component "box" .}}{{Component "box"}}nested{{/Component}}{{end}}`, data)
+	assert.Equal(t, `<b>empty</b>|<b><b>nested</b></b>`, r)
+
+	_, err := c.Expand([]byte(`{{Component "card" "title" .Title}}body{{end}}`))
+	assert.ErrorIs(t, err, ErrComponentSlot)
+	_, err = c.Expand([]byte(`{{Component "card" "size" "lg"}}{{Fill "header"}}h{{/Fill}}{{end}}`))
+	assert.ErrorIs(t, err, ErrComponentProps)
+	_, err = c.Expand([]byte(`{{Component "box"}}{{Fill "unknown"}}x{{/Fill}}{{end}}`))
+	assert.ErrorIs(t, err, ErrComponentSlot)
+	_, err = c.Expand([]byte(`{{Component "box"}}{{if .}}x{{end}}`))
+	assert.ErrorIs(t, err, ErrComponentSyntax)
+	_, err = c.Expand([]byte(`{{Component "missing"/}}`))
+	assert.ErrorIs(t, err, ErrComponentNotFound)
+	_, err = c.Expand([]byte(`{{Component "loop"/}}`))
+	assert.ErrorIs(t, err, ErrComponentRecursive)
+}
@@ -0,0 +1,76 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package driver
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	_ Manager    = &FSManager{}
+	_ FileLister = &FSManager{}
+)
+
+// NewFSManager 创建从 fs.FS(例如 embed.FS 或 handler/embed.FileSystems)读取模板的管理器。
+// rootDir 为模板引擎中的模板根目录，读取时会将其映射为 fs.FS 的根目录。该管理器不监控文件变动
+func NewFSManager(fsys fs.FS, rootDir string) *FSManager {
+	return &FSManager{
+		BaseManager: &BaseManager{},
+		FS:          fsys,
+		RootDir:     rootDir,
+	}
+}
+
+type FSManager struct {
+	*BaseManager
+	FS      fs.FS
+	RootDir string
+}
+
+func (a *FSManager) relPath(name string) string {
+	if len(a.RootDir) > 0 {
+		if rel, err := filepath.Rel(a.RootDir, name); err == nil && !strings.HasPrefix(rel, `..`) {
+			name = rel
+		}
+	}
+	name = path.Clean(filepath.ToSlash(name))
+	return strings.TrimPrefix(name, `/`)
+}
+
+func (a *FSManager) GetTemplate(name string) ([]byte, error) {
+	return fs.ReadFile(a.FS, a.relPath(name))
+}
+
+func (a *FSManager) ListFiles(dir string, ext string) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(a.FS, a.relPath(dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ext) {
+			return nil
+		}
+		files = append(files, filepath.Join(a.RootDir, filepath.FromSlash(p)))
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,109 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package driver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webx-top/echo"
+)
+
+// Precompiler 支持预编译的模板引擎
+type Precompiler interface {
+	// Precompile 编译模板目录中的全部模板并返回汇总的错误报告。c 为 nil 时使用空的上下文
+	Precompile(c echo.Context) *PrecompileReport
+}
+
+// FileLister 可以列出模板文件的模板管理器
+type FileLister interface {
+	// ListFiles 返回 dir 中所有扩展名为 ext 的文件路径
+	ListFiles(dir string, ext string) ([]string, error)
+}
+
+// PrecompileError 单个模板的编译错误
+type PrecompileError struct {
+	Template string
+	Err      error
+}
+
+func (p *PrecompileError) Error() string {
+	return p.Template + `: ` + p.Err.Error()
+}
+
+func (p *PrecompileError) Unwrap() error {
+	return p.Err
+}
+
+// PrecompileReport 预编译结果
+type PrecompileReport struct {
+	Compiled []string // 已编译的模板
+	Skipped  []string // 作为母版或组件被其它模板引用而未单独编译的模板
+	Errors   []*PrecompileError
+	Elapsed  time.Duration
+}
+
+func (r *PrecompileReport) AddError(tmpl string, err error) {
+	r.Errors = append(r.Errors, &PrecompileError{Template: tmpl, Err: err})
+}
+
+// Err 返回汇总的错误，没有错误时返回 nil
+func (r *PrecompileReport) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	errs := make([]error, len(r.Errors))
+	for i, err := range r.Errors {
+		errs[i] = err
+	}
+	return errors.Join(errs...)
+}
+
+func (r *PrecompileReport) String() string {
+	var b strings.Builder
+	b.WriteString(`compiled: ` + strconv.Itoa(len(r.Compiled)) + `, skipped: ` + strconv.Itoa(len(r.Skipped)) + `, errors: ` + strconv.Itoa(len(r.Errors)) + `, elapsed: ` + r.Elapsed.String())
+	for _, err := range r.Errors {
+		b.WriteString("\n  ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// ListFiles 列出模板文件。优先使用模板管理器(例如基于 fs.FS 的 FSManager)，否则遍历本地目录
+func ListFiles(mgr Manager, dir string, ext string) ([]string, error) {
+	if lister, ok := mgr.(FileLister); ok {
+		return lister.ListFiles(dir, ext)
+	}
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ext) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, err
+}
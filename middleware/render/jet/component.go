@@ -0,0 +1,120 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package jet
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/admpub/jet/v6"
+
+	"github.com/webx-top/echo/middleware/render/driver"
+)
+
+// ComponentExtensions 查找组件文件时依次尝试的扩展名
+var ComponentExtensions = []string{``, `.jet`, `.html.jet`, `.jet.html`, `.html`}
+
+var _ driver.ComponentSyntax = &Syntax{}
+
+// Syntax Jet 模板的组件方言。组件内通过 props 变量访问参数
+type Syntax struct{}
+
+func (s *Syntax) Delims() (string, string) {
+	return `{{`, `}}`
+}
+
+func (s *Syntax) BlockKeywords() []string {
+	return []string{`if`, `range`, `block`, `macro`, `try`}
+}
+
+func (s *Syntax) Scope(props string, body string) string {
+	return `{{ if true }}{{ props := ` + props + ` }}` + body + `{{ end }}`
+}
+
+func (s *Syntax) Props(args []string) string {
+	switch len(args) {
+	case 0:
+		return `.`
+	case 1:
+		return args[0]
+	default:
+		return driver.ComponentPropsFunc + `(` + strings.Join(args, `, `) + `)`
+	}
+}
+
+func readComponent(loader jet.Loader, name string) ([]byte, error) {
+	file := path.Join(`/`, driver.DefaultComponentDir, name)
+	for _, ext := range ComponentExtensions {
+		if !loader.Exists(file + ext) {
+			continue
+		}
+		r, err := loader.Open(file + ext)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	return nil, os.ErrNotExist
+}
+
+// componentLoader 在加载模板时展开其中的组件
+type componentLoader struct {
+	jet.Loader
+	components *driver.Components
+}
+
+func (l *componentLoader) Open(templatePath string) (io.ReadCloser, error) {
+	r, err := l.Loader.Open(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err = l.components.Expand(b)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// fsLoader 从 fs.FS 中加载模板，模板路径相对于 fs.FS 的根目录
+type fsLoader struct {
+	fs fs.FS
+}
+
+func (l *fsLoader) name(templatePath string) string {
+	return strings.TrimPrefix(path.Clean(`/`+templatePath), `/`)
+}
+
+func (l *fsLoader) Exists(templatePath string) bool {
+	_, err := fs.Stat(l.fs, l.name(templatePath))
+	return err == nil
+}
+
+func (l *fsLoader) Open(templatePath string) (io.ReadCloser, error) {
+	return l.fs.Open(l.name(templatePath))
+}
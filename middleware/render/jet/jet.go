@@ -47,8 +47,14 @@ func New(templateDir string, args ...logger.Logger) driver.Driver {
 	a := &Jet{
 		NopRenderer: &driver.NopRenderer{},
 		templateDir: templateDir,
-		set:         jet.NewSet(jet.NewOSFileSystemLoader(templateDir)),
 	}
+	a.loader = &componentLoader{Loader: jet.NewOSFileSystemLoader(templateDir)}
+	a.components = driver.NewComponents(&Syntax{}, func(name string) ([]byte, error) {
+		return readComponent(a.loader.Loader, name)
+	})
+	a.loader.components = a.components
+	a.set = jet.NewSet(a.loader)
+	a.set.AddGlobal(driver.ComponentPropsFunc, driver.ComponentPropsMap)
 	if len(args) > 0 {
 		a.logger = args[0]
 	} else {
@@ -60,10 +66,21 @@ func New(templateDir string, args ...logger.Logger) driver.Driver {
 type Jet struct {
 	*driver.NopRenderer
 	set           *jet.Set
+	loader        *componentLoader
 	templateDir   string
 	logger        logger.Logger
 	debug         bool
 	tmplPathFixer func(echo.Context, string) string
+	components    *driver.Components
+}
+
+// Components 返回组件注册表
+func (a *Jet) Components() *driver.Components {
+	return a.components
+}
+
+func (a *Jet) ClearCache() {
+	a.components.ClearCache()
 }
 
 func (a *Jet) Debug() bool {
@@ -123,6 +140,10 @@ func (a *Jet) RenderBy(w io.Writer, tmplName string, tmplContent func(string) ([
 	if err != nil {
 		return err
 	}
+	b, err = a.components.Expand(b)
+	if err != nil {
+		return err
+	}
 	tmpl, err := a.set.Parse(tmplName, string(b))
 	if err != nil {
 		return err
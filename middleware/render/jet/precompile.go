@@ -0,0 +1,88 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package jet
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware/render/driver"
+)
+
+var _ driver.Precompiler = &Jet{}
+
+// PrecompileExtensions 预编译时需要编译的模板文件扩展名
+var PrecompileExtensions = []string{`.jet`, `.html`}
+
+// UseFS 从 fs.FS(例如 embed.FS 或 handler/embed.FileSystems)中读取模板
+func (a *Jet) UseFS(fsys fs.FS) *Jet {
+	a.SetManager(driver.NewFSManager(fsys, a.templateDir))
+	return a
+}
+
+// SetManager 设置模板管理器。基于 fs.FS 的管理器(FSManager)同时用于加载模板
+func (a *Jet) SetManager(mgr driver.Manager) {
+	a.NopRenderer.SetManager(mgr)
+	if m, ok := mgr.(*driver.FSManager); ok {
+		a.loader.Loader = &fsLoader{fs: m.FS}
+		a.ClearCache()
+	}
+}
+
+// Precompile 编译模板目录中的全部模板(组件目录除外)并返回汇总的错误报告
+func (a *Jet) Precompile(_ echo.Context) *driver.PrecompileReport {
+	start := time.Now()
+	report := &driver.PrecompileReport{}
+	defer func() {
+		report.Elapsed = time.Since(start)
+	}()
+	componentDir := filepath.Join(a.templateDir, driver.DefaultComponentDir) + string(filepath.Separator)
+	var files []string
+	for _, ext := range PrecompileExtensions {
+		list, err := driver.ListFiles(a.Manager(), a.templateDir, ext)
+		if err != nil {
+			report.AddError(a.templateDir, err)
+			return report
+		}
+		files = append(files, list...)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		rel, err := filepath.Rel(a.templateDir, file)
+		if err != nil {
+			report.AddError(file, err)
+			continue
+		}
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(file, componentDir) {
+			report.Skipped = append(report.Skipped, name)
+			continue
+		}
+		if _, err := a.set.GetTemplate(`/` + name); err != nil {
+			report.AddError(name, err)
+			continue
+		}
+		report.Compiled = append(report.Compiled, name)
+	}
+	return report
+}
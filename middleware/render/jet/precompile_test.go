@@ -0,0 +1,31 @@
+package jet
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+)
+
+func TestPrecompileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		`index.jet`:           {Data: []byte(`<main>{{ include "/partial/nav.jet" }}{{ . }}</main>`)},
+		`partial/nav.jet`:     {Data: []byte(`<nav></nav>`)},
+		`components/card.jet`: {Data: []byte(`<div></div>`)},
+		`broken.jet`:          {Data: []byte(`{{ if . }}`)},
+	}
+	d := New(`/templates-not-exists`).(*Jet)
+	d.UseFS(fsys)
+	report := d.Precompile(nil)
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, `broken.jet`, report.Errors[0].Template)
+	assert.Equal(t, []string{`index.jet`, `partial/nav.jet`}, report.Compiled)
+	assert.Equal(t, []string{`components/card.jet`}, report.Skipped)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, d.Render(buf, `/index.jet`, `x`, echo.NewContext(nil, nil, echo.New())))
+	assert.Equal(t, `<main><nav></nav>x</main>`, buf.String())
+}
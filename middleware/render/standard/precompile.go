@@ -0,0 +1,102 @@
+/*
+
+   Copyright 2016 Wenhui Shen <www.webx.top>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+
+*/
+
+package standard
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware/render/driver"
+)
+
+var _ driver.Precompiler = &Standard{}
+
+// UseFS 从 fs.FS(例如 embed.FS 或 handler/embed.FileSystems)中读取模板，不再监控模板目录
+func (a *Standard) UseFS(fsys fs.FS) *Standard {
+	a.SetManager(driver.NewFSManager(fsys, a.TemplateDir))
+	return a
+}
+
+// Precompile 编译模板目录中的全部模板并缓存编译结果，返回汇总的错误报告。
+// 被 Extend 引用的母版和组件目录中的模板不会单独编译，而是随引用它们的模板一起编译
+func (a *Standard) Precompile(c echo.Context) *driver.PrecompileReport {
+	start := time.Now()
+	report := &driver.PrecompileReport{}
+	defer func() {
+		report.Elapsed = time.Since(start)
+	}()
+	if c == nil {
+		c = echo.NewContext(nil, nil, echo.New())
+	}
+	files, err := driver.ListFiles(a.TemplateMgr, a.TemplateDir, a.Ext)
+	if err != nil {
+		report.AddError(a.TemplateDir, err)
+		return report
+	}
+	sort.Strings(files)
+	layouts := map[string]struct{}{}
+	broken := map[string]struct{}{}
+	for _, file := range files {
+		if a.isComponentFile(file) {
+			continue
+		}
+		b, err := a.RawContent(file)
+		if err != nil {
+			report.AddError(a.relName(file), err)
+			continue
+		}
+		content := string(b)
+		for _, m := range a.extTagRegex.FindAllStringSubmatch(content, -1) {
+			layouts[a.TmplPath(c, m[1]+a.Ext)] = struct{}{}
+		}
+		for _, m := range a.incTagRegex.FindAllStringSubmatch(content, -1) {
+			if _, err := a.RawContent(a.TmplPath(c, m[1]+a.Ext)); err != nil {
+				report.AddError(a.relName(file), err)
+				broken[file] = struct{}{}
+			}
+		}
+	}
+	for _, file := range files {
+		name := a.relName(file)
+		if _, ok := layouts[file]; ok || a.isComponentFile(file) {
+			report.Skipped = append(report.Skipped, name)
+			continue
+		}
+		if _, ok := broken[file]; ok {
+			continue
+		}
+		if _, err := a.parse(c, strings.TrimSuffix(name, a.Ext), a.RawContent); err != nil {
+			report.AddError(name, err)
+			continue
+		}
+		report.Compiled = append(report.Compiled, name)
+	}
+	return report
+}
+
+func (a *Standard) relName(file string) string {
+	if rel, err := filepath.Rel(a.TemplateDir, file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
@@ -0,0 +1,46 @@
+package standard
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo/middleware/render/driver"
+)
+
+func TestPrecompile(t *testing.T) {
+	fsys := fstest.MapFS{
+		`layout.html`:          {Data: []byte(`<main>{{Block "body"}}{{/Block}}</main>`)},
+		`index.html`:           {Data: []byte(`{{Extend "layout"}}{{Block "body"}}{{Include "partial/nav"}}{{Component "card" .}}{{.}}{{end}}{{/Block}}`)},
+		`partial/nav.html`:     {Data: []byte(`<nav></nav>`)},
+		`components/card.html`: {Data: []byte(`<div>{{Slot "default"/}}</div>`)},
+		`broken.html`:          {Data: []byte(`{{if .}}`)},
+		`missing.html`:         {Data: []byte(`{{Include "partial/none"}}`)},
+	}
+	d := New(`/templates`).(*Standard)
+	d.UseFS(fsys)
+	report := d.Precompile(nil)
+	assert.Equal(t, []string{`missing.html`, `broken.html`}, errorTemplates(report))
+	assert.Equal(t, []string{`index.html`, `partial/nav.html`}, report.Compiled)
+	assert.Equal(t, []string{`components/card.html`, `layout.html`}, report.Skipped)
+	assert.Error(t, report.Err())
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, d.Render(buf, `index`, `x`, nil))
+	assert.Equal(t, `<main><nav></nav><div>x</div></main>`, buf.String())
+
+	delete(fsys, `broken.html`)
+	delete(fsys, `missing.html`)
+	d.ClearCache()
+	assert.NoError(t, d.Precompile(nil).Err())
+}
+
+func errorTemplates(report *driver.PrecompileReport) []string {
+	names := make([]string, len(report.Errors))
+	for i, err := range report.Errors {
+		names[i] = err.Template
+	}
+	return names
+}
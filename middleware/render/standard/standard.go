@@ -63,6 +63,7 @@ func New(templateDir string, args ...logger.Logger) driver.Driver {
 		BlockTag:          "Block",
 		SuperTag:          "Super",
 		StripTag:          "Strip",
+		ComponentDir:      driver.DefaultComponentDir,
 		Ext:               ".html",
 		debug:             Debug,
 		fileEvents:        make([]func(string), 0),
@@ -73,6 +74,8 @@ func New(templateDir string, args ...logger.Logger) driver.Driver {
 	} else {
 		t.logger = log.New("render-standard")
 	}
+	t.componentSyntax = &driver.GoTemplateSyntax{}
+	t.components = driver.NewComponents(t.componentSyntax, t.componentContent)
 	t.InitRegexp()
 	t.SetManager(manager.Default)
 	return t
@@ -98,6 +101,7 @@ type Standard struct {
 	BlockTag           string
 	SuperTag           string
 	StripTag           string
+	ComponentDir       string // 组件模板所在目录(相对于 TemplateDir)
 	Ext                string
 	tmplPathFixer      func(echo.Context, string) string
 	debug              bool
@@ -108,6 +112,8 @@ type Standard struct {
 	quotedRight        string
 	quotedRfirst       string
 	sg                 singleflight.Group
+	components         *driver.Components
+	componentSyntax    *driver.GoTemplateSyntax
 }
 
 func (a *Standard) Debug() bool {
@@ -150,6 +156,34 @@ func (a *Standard) SetFuncMap(fn func() map[string]interface{}) {
 	a.getFuncs = fn
 }
 
+// Components 返回组件注册表
+func (a *Standard) Components() *driver.Components {
+	return a.components
+}
+
+func (a *Standard) componentContent(name string) ([]byte, error) {
+	file := filepath.Join(a.TemplateDir, a.ComponentDir, name+a.Ext)
+	var (
+		b   []byte
+		err error
+	)
+	if a.TemplateMgr != nil {
+		b, err = a.TemplateMgr.GetTemplate(file)
+	} else {
+		b, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimPrefix(b, bytesBOM)
+	return a.preprocess(b), nil
+}
+
+func (a *Standard) isComponentFile(name string) bool {
+	dir := filepath.Join(a.TemplateDir, a.ComponentDir)
+	return strings.HasPrefix(name, dir+string(filepath.Separator))
+}
+
 func (a *Standard) deleteCachedRelation(name string) {
 	if cs, ok := a.CachedRelation.GetOk(name); ok {
 		_ = cs
@@ -177,7 +211,13 @@ func (a *Standard) Init() {
 			if typ == "dir" {
 				return
 			}
-			a.deleteCachedRelation(name)
+			if a.isComponentFile(name) {
+				a.components.ClearCache()
+				a.CachedRelation.Reset()
+				a.logger.Info("remove cached template object")
+			} else {
+				a.deleteCachedRelation(name)
+			}
 			for _, fn := range a.fileEvents {
 				fn(name)
 			}
@@ -222,6 +262,8 @@ func (a *Standard) InitRegexp() {
 	a.quotedLeft = regexp.QuoteMeta(a.DelimLeft)
 	a.quotedRight = regexp.QuoteMeta(a.DelimRight)
 	a.quotedRfirst = regexp.QuoteMeta(a.DelimRight[0:1])
+	a.componentSyntax.Left = a.DelimLeft
+	a.componentSyntax.Right = a.DelimRight
 
 	//{{Include "tmpl"}} or {{Include "tmpl" .}}
 	a.incTagRegex = regexp.MustCompile(a.quotedLeft + a.IncludeTag + `[\s]+` + quoteRegex + `(?:[\s]+([^` + a.quotedRfirst + `]+))?[\s]*\/?` + a.quotedRight)
@@ -327,6 +369,11 @@ func (a *Standard) find(c echo.Context, rel *CcRel,
 	if err != nil {
 		return
 	}
+	b, err = a.components.Expand(b)
+	if err != nil {
+		err = parseError(err, string(b))
+		return
+	}
 	content := string(b)
 	subcs := map[string]string{} //子模板内容
 	extcs := map[string]string{} //母板内容
@@ -584,6 +631,7 @@ func (a *Standard) RawContent(tmpl string) (b []byte, e error) {
 	}
 	b = bytes.TrimPrefix(b, bytesBOM)
 	b = a.preprocess(b)
+	b, e = a.components.Expand(b)
 	return
 }
 
@@ -620,6 +668,7 @@ func (a *Standard) ClearCache() {
 		a.TemplateMgr.ClearCache()
 	}
 	a.CachedRelation.Reset()
+	a.components.ClearCache()
 }
 
 func (a *Standard) Close() {
@@ -646,6 +695,7 @@ func setFunc(tplInf *tplInfo, funcMap htmlTpl.FuncMap) htmlTpl.FuncMap {
 		}
 		return true
 	}
+	funcMap[driver.ComponentPropsFunc] = driver.ComponentPropsMap
 	funcMap["hasAnyBlock"] = func(blocks ...string) bool {
 		for _, blockName := range blocks {
 			if _, ok := tplInf.Blocks[blockName]; ok {
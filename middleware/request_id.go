@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
+
 	"github.com/webx-top/echo"
 	"github.com/webx-top/echo/middleware/random"
 )
@@ -17,15 +20,37 @@ type (
 
 		// RequestIDHandler defines a function which is executed for a request id.
 		RequestIDHandler func(echo.Context, string)
+
+		// Header is the name of the request and response header of the ID.
+		// Optional. Default value "X-Request-ID".
+		Header string
+
+		// Validator reports whether the ID of the incoming request can be used.
+		// A new ID is generated if it returns false.
+		// Optional. Default value ValidRequestID.
+		Validator func(string) bool
+
+		// IgnoreIncoming always generates a new ID instead of using the incoming one.
+		// Optional. Default value false.
+		IgnoreIncoming bool
 	}
 )
 
+// requestIDContextKey is a string key, which is also readable from the context
+// of the fasthttp engine (see `engine.Request#SetValue`).
+const requestIDContextKey = `middleware.requestID`
+
 var (
 	// DefaultRequestIDConfig is the default RequestID middleware config.
 	DefaultRequestIDConfig = RequestIDConfig{
 		Skipper:   echo.DefaultSkipper,
 		Generator: generator,
+		Header:    echo.HeaderXRequestID,
+		Validator: ValidRequestID,
 	}
+
+	// RequestIDMaxLength is the maximum length of the incoming ID accepted by ValidRequestID.
+	RequestIDMaxLength = 128
 )
 
 // RequestID returns a X-Request-ID middleware.
@@ -34,6 +59,10 @@ func RequestID() echo.MiddlewareFuncd {
 }
 
 // RequestIDWithConfig returns a X-Request-ID middleware with config.
+// The ID is stored by `echo.RequestIDer#SetRequestID`, which adds it to the entries of
+// `c.Logger()`, set to the request header to be forwarded by the Proxy middleware,
+// and stored by `c.Request().SetValue` for `RequestIDTransport`, so that
+// `RequestIDFromContext(c.StdContext())` returns it on all the engines.
 func RequestIDWithConfig(config RequestIDConfig) echo.MiddlewareFuncd {
 	// Defaults
 	if config.Skipper == nil {
@@ -42,6 +71,12 @@ func RequestIDWithConfig(config RequestIDConfig) echo.MiddlewareFuncd {
 	if config.Generator == nil {
 		config.Generator = generator
 	}
+	if len(config.Header) == 0 {
+		config.Header = DefaultRequestIDConfig.Header
+	}
+	if config.Validator == nil {
+		config.Validator = DefaultRequestIDConfig.Validator
+	}
 
 	return func(next echo.Handler) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -49,11 +84,20 @@ func RequestIDWithConfig(config RequestIDConfig) echo.MiddlewareFuncd {
 				return next.Handle(c)
 			}
 
-			rid := c.Header(echo.HeaderXRequestID)
-			if len(rid) == 0 {
+			req := c.Request()
+			var rid string
+			if !config.IgnoreIncoming {
+				rid = req.Header().Get(config.Header)
+			}
+			if len(rid) == 0 || !config.Validator(rid) {
 				rid = config.Generator()
+				req.Header().Set(config.Header, rid)
 			}
-			c.Response().Header().Set(echo.HeaderXRequestID, rid)
+			if r, ok := c.(echo.RequestIDer); ok {
+				r.SetRequestID(rid)
+			}
+			req.SetValue(requestIDContextKey, rid)
+			c.Response().Header().Set(config.Header, rid)
 			if config.RequestIDHandler != nil {
 				config.RequestIDHandler(c, rid)
 			}
@@ -63,6 +107,64 @@ func RequestIDWithConfig(config RequestIDConfig) echo.MiddlewareFuncd {
 	}
 }
 
+// ValidRequestID reports whether the ID is not longer than RequestIDMaxLength and
+// only contains letters, digits and `-_.:+=/@`, so that it is safe to log.
+func ValidRequestID(id string) bool {
+	if len(id) > RequestIDMaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		b := id[i]
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		case b == '-', b == '_', b == '.', b == ':', b == '+', b == '=', b == '/', b == '@':
+		default:
+			return false
+		}
+	}
+	return len(id) > 0
+}
+
+// ContextWithRequestID returns a copy of ctx with the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by the RequestID middleware.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// RequestIDTransport is a http.RoundTripper which sets the `X-Request-ID` header
+// of the outgoing requests to the request ID in their context, e.g.
+//
+//	req, _ := http.NewRequestWithContext(c.StdContext(), `GET`, url, nil)
+//	resp, err := (&http.Client{Transport: &middleware.RequestIDTransport{}}).Do(req)
+type RequestIDTransport struct {
+	// Base is the underlying RoundTripper. Default value http.DefaultTransport.
+	Base http.RoundTripper
+
+	// Header is the name of the header. Default value "X-Request-ID".
+	Header string
+}
+
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if len(header) == 0 {
+		header = echo.HeaderXRequestID
+	}
+	if rid := RequestIDFromContext(req.Context()); len(rid) > 0 && len(req.Header.Get(header)) == 0 {
+		req = req.Clone(req.Context())
+		req.Header.Set(header, rid)
+	}
+	return base.RoundTrip(req)
+}
+
 func generator() string {
 	return random.String(32)
 }
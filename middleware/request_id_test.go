@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/logger"
+	test "github.com/webx-top/echo/testing"
+)
+
+type testRecordLogger struct {
+	logger.Base
+	lines []string
+}
+
+func (l *testRecordLogger) Info(args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+func (l *testRecordLogger) Infof(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *testRecordLogger) Error(args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+func TestRequestID(t *testing.T) {
+	var outbound string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Get(echo.HeaderXRequestID)
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+
+	log := &testRecordLogger{}
+	e := echo.New()
+	e.SetLogger(log)
+	e.Use(RequestID())
+	e.Get(`/`, func(c echo.Context) error {
+		c.Logger().Info(`hello`)
+		c.Logger().Infof(`100%% %s`, `done`)
+		assert.Equal(t, echo.RequestIDOf(c), RequestIDFromContext(c.StdContext()))
+		return c.String(echo.RequestIDOf(c))
+	})
+	e.Get(`/proxy`, func(c echo.Context) error {
+		return nil
+	}, Proxy(NewRandomBalancer([]ProxyTargeter{&ProxyTarget{URL: target}})))
+	e.Get(`/outbound`, func(c echo.Context) error {
+		req, _ := http.NewRequestWithContext(c.StdContext(), http.MethodGet, backend.URL, nil)
+		resp, err := (&http.Client{Transport: &RequestIDTransport{}}).Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+	e.Get(`/panic`, func(c echo.Context) error {
+		panic(errors.New(`boom`))
+	}, Recover())
+	e.Commit()
+	client := test.NewClient(e)
+
+	// honours the incoming ID
+	client.Get(`/`).Header(echo.HeaderXRequestID, `abc-123`).Do().Expect(t).
+		Body(`abc-123`).
+		Header(echo.HeaderXRequestID, `abc-123`)
+	assert.Equal(t, []string{`request_id=abc-123 hello`, `request_id=abc-123 100% done`}, log.lines)
+
+	// generates a new ID for the invalid ones
+	resp := client.Get(`/`).Header(echo.HeaderXRequestID, "bad\nid").Do()
+	assert.Len(t, resp.String(), 32)
+	assert.Equal(t, resp.String(), resp.Header.Get(echo.HeaderXRequestID))
+	assert.False(t, ValidRequestID(strings.Repeat(`a`, RequestIDMaxLength+1)))
+	assert.False(t, ValidRequestID(``))
+
+	// forwards the ID
+	resp = client.Get(`/proxy`).Do()
+	assert.NotEmpty(t, outbound)
+	assert.Equal(t, resp.Header.Get(echo.HeaderXRequestID), outbound)
+	outbound = ``
+	client.Get(`/outbound`).Header(echo.HeaderXRequestID, `out-1`).Do().Expect(t).Status(http.StatusOK)
+	assert.Equal(t, `out-1`, outbound)
+
+	// includes the ID in the panic
+	log.lines = nil
+	client.Get(`/panic`).Header(echo.HeaderXRequestID, `panic-1`).Do().Expect(t).Status(http.StatusInternalServerError)
+	assert.Len(t, log.lines, 1)
+	assert.True(t, strings.HasPrefix(log.lines[0], `request_id=panic-1 [request_id: panic-1] [PANIC RECOVER] boom`), log.lines[0])
+}
+
+func TestRequestIDFastHTTP(t *testing.T) {
+	var outbound string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Get(echo.HeaderXRequestID)
+	}))
+	defer backend.Close()
+
+	e := echo.New()
+	e.Use(RequestID())
+	e.Get(`/outbound`, func(c echo.Context) error {
+		if rid := RequestIDFromContext(c.StdContext()); rid != echo.RequestIDOf(c) {
+			return c.String(`context: ` + rid)
+		}
+		req, _ := http.NewRequestWithContext(c.StdContext(), http.MethodGet, backend.URL, nil)
+		resp, err := (&http.Client{Transport: &RequestIDTransport{}}).Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return c.String(echo.RequestIDOf(c))
+	})
+	e.Commit()
+	client := test.NewClient(e, test.FastHTTPAdapter)
+
+	client.Get(`/outbound`).Header(echo.HeaderXRequestID, `fast-1`).Do().Expect(t).
+		Status(http.StatusOK).
+		Body(`fast-1`)
+	assert.Equal(t, `fast-1`, outbound)
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/webx-top/echo"
 )
@@ -53,6 +54,47 @@ type (
 		// trusted web page context.
 		// Optional. Default value "".
 		ContentSecurityPolicy string `json:"content_security_policy"`
+
+		// CSP builds the `Content-Security-Policy` header instead of `ContentSecurityPolicy`.
+		// If the policy contains `CSPNonceSource`, a nonce is generated for every request,
+		// which can be got by `CSPNonce(c)` or `{{CSPNonce}}` in the templates.
+		// `CSPNonceSource` in `ContentSecurityPolicy` is replaced too.
+		// Optional. Default value nil.
+		CSP *CSP `json:"-"`
+
+		// CSPReportOnly sends the policy in the `Content-Security-Policy-Report-Only` header,
+		// so that the violations are reported but not blocked.
+		// Optional. Default value false.
+		CSPReportOnly bool `json:"csp_report_only"`
+
+		// ReportingEndpoints sets the `Reporting-Endpoints` header defining the groups
+		// used by the `report-to` directive, e.g. `csp="https://example.com/csp-report"`.
+		// Optional. Default value "".
+		ReportingEndpoints string `json:"reporting_endpoints"`
+
+		// PermissionsPolicy sets the `Permissions-Policy` header, e.g. `camera=(), geolocation=(self)`.
+		// Optional. Default value "".
+		PermissionsPolicy string `json:"permissions_policy"`
+
+		// CrossOriginOpenerPolicy sets the `Cross-Origin-Opener-Policy` header.
+		// Optional. Default value "".
+		// Possible values: "unsafe-none", "same-origin-allow-popups", "same-origin".
+		CrossOriginOpenerPolicy string `json:"cross_origin_opener_policy"`
+
+		// CrossOriginEmbedderPolicy sets the `Cross-Origin-Embedder-Policy` header.
+		// Optional. Default value "".
+		// Possible values: "unsafe-none", "require-corp", "credentialless".
+		CrossOriginEmbedderPolicy string `json:"cross_origin_embedder_policy"`
+
+		// CrossOriginResourcePolicy sets the `Cross-Origin-Resource-Policy` header.
+		// Optional. Default value "".
+		// Possible values: "same-site", "same-origin", "cross-origin".
+		CrossOriginResourcePolicy string `json:"cross_origin_resource_policy"`
+
+		// ReferrerPolicy sets the `Referrer-Policy` header.
+		// Optional. Default value "".
+		// Possible values: "no-referrer", "same-origin", "strict-origin-when-cross-origin", etc.
+		ReferrerPolicy string `json:"referrer_policy"`
 	}
 )
 
@@ -81,6 +123,23 @@ func SecureWithConfig(config SecureConfig) echo.MiddlewareFuncd {
 	if config.Skipper == nil {
 		config.Skipper = DefaultSecureConfig.Skipper
 	}
+	policy := config.ContentSecurityPolicy
+	if config.CSP != nil {
+		policy = config.CSP.String()
+	}
+	policyHeader := echo.HeaderContentSecurityPolicy
+	if config.CSPReportOnly {
+		policyHeader = echo.HeaderContentSecurityPolicyReportOnly
+	}
+	withNonce := strings.Contains(policy, CSPNonceSource)
+	headers := [][2]string{
+		{echo.HeaderReportingEndpoints, config.ReportingEndpoints},
+		{echo.HeaderPermissionsPolicy, config.PermissionsPolicy},
+		{echo.HeaderCrossOriginOpenerPolicy, config.CrossOriginOpenerPolicy},
+		{echo.HeaderCrossOriginEmbedderPolicy, config.CrossOriginEmbedderPolicy},
+		{echo.HeaderCrossOriginResourcePolicy, config.CrossOriginResourcePolicy},
+		{echo.HeaderReferrerPolicy, config.ReferrerPolicy},
+	}
 
 	return func(next echo.Handler) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -107,8 +166,18 @@ func SecureWithConfig(config SecureConfig) echo.MiddlewareFuncd {
 				}
 				hdr.Set(echo.HeaderStrictTransportSecurity, fmt.Sprintf("max-age=%d%s", config.HSTSMaxAge, subdomains))
 			}
-			if config.ContentSecurityPolicy != "" {
-				hdr.Set(echo.HeaderContentSecurityPolicy, config.ContentSecurityPolicy)
+			if withNonce {
+				nonce := NewCSPNonce()
+				c.Internal().Set(CSPNonceKey, nonce)
+				c.SetFunc(`CSPNonce`, func() string { return nonce })
+				hdr.Set(policyHeader, WithCSPNonce(policy, nonce))
+			} else if policy != "" {
+				hdr.Set(policyHeader, policy)
+			}
+			for _, h := range headers {
+				if h[1] != "" {
+					hdr.Set(h[0], h[1])
+				}
 			}
 			return next.Handle(c)
 		}
@@ -157,6 +157,15 @@ func (s *Session) Session() *sessions.Session {
 	return s.session
 }
 
+// Values returns a copy of the values in the session.
+func (s *Session) Values() map[string]interface{} {
+	values := make(map[string]interface{}, len(s.Session().Values))
+	for key, val := range s.Session().Values {
+		values[fmt.Sprint(key)] = val
+	}
+	return values
+}
+
 func (s *Session) Written() bool {
 	return s.written
 }
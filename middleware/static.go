@@ -1,12 +1,17 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"html/template"
+	"io"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/admpub/log"
@@ -48,11 +53,71 @@ type (
 		MaxAge     time.Duration   `json:"maxAge"`
 		TrimPrefix string          `json:"trimPrefix"`
 
+		// Precompressed serves the `.br` or `.gz` file next to the requested file
+		// if the client accepts the encoding, e.g. `app.js.br` for `app.js`.
+		Precompressed bool `json:"precompressed"`
+
+		// ETag sets the strong ETag of the content hash, which is cached by the
+		// modification time and size of the file.
+		ETag bool `json:"etag"`
+
+		// SPAIndex is the file of the single page application in Root, e.g. `index.html`,
+		// which is served for the missing paths without file extension requested
+		// by browsers (with `text/html` in the Accept header). The missing assets,
+		// whose paths have file extensions, are still not found.
+		SPAIndex string `json:"spaIndex"`
+
 		open   func(string) (http.File, error)
 		render func(echo.Context, interface{}) error
+		etags  sync.Map // path => *staticETag
+	}
+
+	staticETag struct {
+		modTime time.Time
+		size    int64
+		etag    string
 	}
 )
 
+// StaticMIMETypes are the content types by file extension used by the Static middleware
+// before `echo.ContentTypeByExtension`, so that the modern formats do not depend
+// on the MIME types of the system.
+var StaticMIMETypes = map[string]string{
+	`.html`:        echo.MIMETextHTMLCharsetUTF8,
+	`.css`:         `text/css; charset=utf-8`,
+	`.js`:          `text/javascript; charset=utf-8`,
+	`.mjs`:         `text/javascript; charset=utf-8`,
+	`.json`:        echo.MIMEApplicationJSONCharsetUTF8,
+	`.map`:         echo.MIMEApplicationJSONCharsetUTF8,
+	`.webmanifest`: `application/manifest+json`,
+	`.wasm`:        `application/wasm`,
+	`.svg`:         `image/svg+xml`,
+	`.webp`:        `image/webp`,
+	`.avif`:        `image/avif`,
+	`.ico`:         `image/x-icon`,
+	`.woff`:        `font/woff`,
+	`.woff2`:       `font/woff2`,
+	`.txt`:         echo.MIMETextPlainCharsetUTF8,
+	`.xml`:         echo.MIMEApplicationXMLCharsetUTF8,
+}
+
+// StaticContentType returns the content type of the file name.
+func StaticContentType(name string) string {
+	if t, ok := StaticMIMETypes[strings.ToLower(filepath.Ext(name))]; ok {
+		return t
+	}
+	return echo.ContentTypeByExtension(name)
+}
+
+// staticEncodings are the precompressed encodings in order of preference.
+var staticEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{`br`, `.br`},
+	{`gzip`, `.gz`},
+}
+
 func Static(options ...*StaticOptions) echo.MiddlewareFunc {
 	// Default options
 	opts := new(StaticOptions)
@@ -179,6 +244,7 @@ func (s *StaticOptions) findFile(c echo.Context, root string, hasIndex bool, fil
 			if err != nil {
 				return echo.ErrNotFound
 			}
+			defer fp.Close()
 			fi, err = fp.Stat()
 			if err != nil || fi.IsDir() {
 				if s.Browse {
@@ -186,6 +252,7 @@ func (s *StaticOptions) findFile(c echo.Context, root string, hasIndex bool, fil
 				}
 				return echo.ErrNotFound
 			}
+			absFile = indexFile
 		} else {
 			if s.Browse {
 				return listDirByCustomFS(absFile, file, c, render, opener)
@@ -193,7 +260,126 @@ func (s *StaticOptions) findFile(c echo.Context, root string, hasIndex bool, fil
 			return echo.ErrNotFound
 		}
 	}
-	return c.ServeContent(fp, fi.Name(), fi.ModTime(), s.MaxAge)
+	return s.serveFile(c, absFile, fp, fi, s.MaxAge, opener)
+}
+
+func (s *StaticOptions) serveFile(c echo.Context, absFile string, fp http.File, fi os.FileInfo, maxAge time.Duration, opener func(string) (http.File, error)) error {
+	name := fi.Name()
+	hdr := c.Response().Header()
+	var content http.File = fp
+	var encoding string
+	if s.Precompressed {
+		hdr.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+		acceptEncoding := c.Request().Header().Get(echo.HeaderAcceptEncoding)
+		for _, enc := range staticEncodings {
+			if !acceptsEncoding(acceptEncoding, enc.encoding) {
+				continue
+			}
+			cfp, err := opener(absFile + enc.extension)
+			if err != nil {
+				continue
+			}
+			cfi, err := cfp.Stat()
+			if err != nil || cfi.IsDir() {
+				cfp.Close()
+				continue
+			}
+			defer cfp.Close()
+			content = cfp
+			absFile += enc.extension
+			fi = cfi
+			encoding = enc.encoding
+			break
+		}
+	}
+	hdr.Set(echo.HeaderContentType, StaticContentType(name))
+	if s.ETag {
+		etag, err := s.etag(absFile, content, fi)
+		if err != nil {
+			return err
+		}
+		hdr.Set(echo.HeaderETag, etag)
+	}
+	// set after the ETag, so that the error response is not marked as compressed
+	if len(encoding) > 0 {
+		hdr.Set(echo.HeaderContentEncoding, encoding)
+	}
+	c.SetCacheHeader(fi.ModTime(), maxAge)
+	c.Response().ServeContent(content, name, fi.ModTime())
+	return nil
+}
+
+// etag returns the strong ETag of the content, which is cached until the
+// modification time or size of the file changes.
+func (s *StaticOptions) etag(absFile string, content io.ReadSeeker, fi os.FileInfo) (string, error) {
+	if v, ok := s.etags.Load(absFile); ok {
+		cached := v.(*staticETag)
+		if cached.modTime.Equal(fi.ModTime()) && cached.size == fi.Size() {
+			return cached.etag, nil
+		}
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return ``, err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return ``, err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(absFile, &staticETag{modTime: fi.ModTime(), size: fi.Size(), etag: etag})
+	return etag, nil
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header accepts the encoding
+// with a non-zero quality.
+func acceptsEncoding(header string, encoding string) bool {
+	for _, part := range strings.Split(header, `,`) {
+		params := strings.Split(part, `;`)
+		name := strings.TrimSpace(params[0])
+		if !strings.EqualFold(name, encoding) && name != `*` {
+			continue
+		}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, `q=`) {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q <= 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isSPARoute reports whether the missing path is a route of the single page application
+// instead of an asset.
+func isSPARoute(c echo.Context, file string) bool {
+	switch c.Request().Method() {
+	case echo.GET, echo.HEAD:
+	default:
+		return false
+	}
+	if len(path.Ext(file)) > 0 {
+		return false
+	}
+	return strings.Contains(c.Header(echo.HeaderAccept), echo.MIMETextHTML)
+}
+
+func (s *StaticOptions) serveSPAIndex(c echo.Context, opener func(string) (http.File, error)) error {
+	absFile := filepath.Join(s.Root, s.SPAIndex)
+	fp, err := opener(absFile)
+	if err != nil {
+		return echo.ErrNotFound
+	}
+	defer fp.Close()
+	fi, err := fp.Stat()
+	if err != nil || fi.IsDir() {
+		return echo.ErrNotFound
+	}
+	// the index is revalidated to load the new assets after deployment
+	c.Response().Header().Set(echo.HeaderCacheControl, `no-cache`)
+	return s.serveFile(c, absFile, fp, fi, 0, opener)
 }
 
 func (s *StaticOptions) Middleware() echo.MiddlewareFunc {
@@ -233,9 +419,6 @@ func (s *StaticOptions) Middleware() echo.MiddlewareFunc {
 			if err != echo.ErrNotFound {
 				return err
 			}
-			if len(s.Fallback) == 0 {
-				return err
-			}
 			for _, fallback := range s.Fallback {
 				if s.Debug {
 					log.GetLogger("echo").Debug(`[middleware][static] `, `fallback ->  `, filepath.Join(fallback, file))
@@ -248,6 +431,9 @@ func (s *StaticOptions) Middleware() echo.MiddlewareFunc {
 					return err
 				}
 			}
+			if len(s.SPAIndex) > 0 && isSPARoute(c, file) {
+				return s.serveSPAIndex(c, opener)
+			}
 			return err
 		})
 	}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+func newTestStaticEcho(t *testing.T) *echo.Echo {
+	root := t.TempDir()
+	files := map[string]string{
+		`index.html`:       `<html>spa</html>`,
+		`app.js`:           `console.log(1)`,
+		`app.js.br`:        `brotli`,
+		`app.js.gz`:        `gzip`,
+		`module.wasm`:      `wasm`,
+		`data.txt`:         `0123456789`,
+		`docs/index.html`:  `docs`,
+		`fonts/font.woff2`: `font`,
+	}
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(file), os.ModePerm))
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+	e := echo.New()
+	e.Use(Static(&StaticOptions{
+		Root:          root,
+		Index:         `index.html`,
+		Precompressed: true,
+		ETag:          true,
+		SPAIndex:      `index.html`,
+	}))
+	e.Commit()
+	return e
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	client := test.NewClient(newTestStaticEcho(t))
+
+	client.Get(`/app.js`).Header(echo.HeaderAcceptEncoding, `gzip, deflate, br`).Do().Expect(t).
+		Status(http.StatusOK).
+		Body(`brotli`).
+		Header(echo.HeaderContentEncoding, `br`).
+		Header(echo.HeaderContentType, `text/javascript; charset=utf-8`).
+		Header(echo.HeaderVary, echo.HeaderAcceptEncoding)
+	client.Get(`/app.js`).Header(echo.HeaderAcceptEncoding, `gzip, br;q=0`).Do().Expect(t).
+		Body(`gzip`).
+		Header(echo.HeaderContentEncoding, `gzip`)
+	client.Get(`/app.js`).Do().Expect(t).
+		Body(`console.log(1)`).
+		NoHeader(echo.HeaderContentEncoding)
+
+	client.Get(`/module.wasm`).Do().Expect(t).Header(echo.HeaderContentType, `application/wasm`)
+	client.Get(`/fonts/font.woff2`).Do().Expect(t).Header(echo.HeaderContentType, `font/woff2`)
+	client.Get(`/docs/`).Do().Expect(t).Body(`docs`)
+}
+
+// brokenBrotliFS opens the files of the OS, whose `.br` files can not be read.
+type brokenBrotliFS struct{}
+
+func (brokenBrotliFS) Open(name string) (http.File, error) {
+	fp, err := os.Open(name)
+	if err != nil || !strings.HasSuffix(name, `.br`) {
+		return fp, err
+	}
+	return brokenFile{fp}, nil
+}
+
+type brokenFile struct {
+	http.File
+}
+
+func (brokenFile) Read([]byte) (int, error) {
+	return 0, errors.New(`read error`)
+}
+
+func TestStaticPrecompressedETagError(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, `app.js`), []byte(`js`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, `app.js.br`), []byte(`brotli`), 0644))
+	e := echo.New()
+	e.Use(Static(&StaticOptions{
+		Root:          root,
+		FS:            brokenBrotliFS{},
+		Precompressed: true,
+		ETag:          true,
+	}))
+	e.Commit()
+
+	test.NewClient(e).Get(`/app.js`).Header(echo.HeaderAcceptEncoding, `br`).Do().Expect(t).
+		Status(http.StatusInternalServerError).
+		NoHeader(echo.HeaderContentEncoding)
+}
+
+func TestStaticETagAndRanges(t *testing.T) {
+	client := test.NewClient(newTestStaticEcho(t))
+
+	resp := client.Get(`/data.txt`).Do()
+	etag := resp.Header.Get(echo.HeaderETag)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, etag, client.Get(`/data.txt`).Do().Header.Get(echo.HeaderETag))
+	brETag := client.Get(`/app.js`).Header(echo.HeaderAcceptEncoding, `br`).Do().Header.Get(echo.HeaderETag)
+	assert.NotEqual(t, brETag, client.Get(`/app.js`).Do().Header.Get(echo.HeaderETag))
+
+	client.Get(`/data.txt`).Header(echo.HeaderIfNoneMatch, etag).Do().Expect(t).Status(http.StatusNotModified)
+	client.Get(`/data.txt`).Header(echo.HeaderIfNoneMatch, `"other"`).Do().Expect(t).Status(http.StatusOK)
+
+	client.Get(`/data.txt`).Header(echo.HeaderRange, `bytes=2-4`).Do().Expect(t).
+		Status(http.StatusPartialContent).
+		Body(`234`).
+		Header(echo.HeaderContentRange, `bytes 2-4/10`)
+	resp = client.Get(`/data.txt`).Header(echo.HeaderRange, `bytes=0-1,8-9`).Do()
+	resp.Expect(t).
+		Status(http.StatusPartialContent).
+		HeaderContains(echo.HeaderContentType, `multipart/byteranges`)
+	assert.Contains(t, resp.String(), "Content-Range: bytes 0-1/10\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n01\r\n")
+	assert.Contains(t, resp.String(), "Content-Range: bytes 8-9/10\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n89\r\n")
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	client := test.NewClient(newTestStaticEcho(t))
+
+	client.Get(`/users/7`).Header(echo.HeaderAccept, `text/html,application/xhtml+xml`).Do().Expect(t).
+		Status(http.StatusOK).
+		Body(`<html>spa</html>`).
+		Header(echo.HeaderCacheControl, `no-cache`).
+		HeaderContains(echo.HeaderContentType, echo.MIMETextHTML)
+	// missing assets
+	resp := client.Get(`/assets/missing.js`).Header(echo.HeaderAccept, `text/html`).Do()
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.False(t, strings.Contains(resp.String(), `spa`))
+	// API clients
+	client.Get(`/users/7`).Header(echo.HeaderAccept, echo.MIMEApplicationJSON).Do().Expect(t).Status(http.StatusNotFound)
+	client.Post(`/users/7`).Header(echo.HeaderAccept, `text/html`).Do().Expect(t).Status(http.StatusNotFound)
+}
@@ -205,6 +205,7 @@ var TplFuncMap template.FuncMap = template.FuncMap{
 	"WithURLParams": com.WithURLParams,
 	"FullURL":       com.FullURL,
 	"IsFullURL":     com.IsFullURL,
+	"CSPNonce":      CSPNonce, // 被 Secure 中间件替换为当前请求的 nonce
 }
 
 var (
@@ -292,6 +293,11 @@ func SafeBase64Decode(s string) string {
 	return r
 }
 
+// CSPNonce 未启用 CSP nonce 时返回空字符串，以便模板中的 {{CSPNonce}} 能正常解析
+func CSPNonce() string {
+	return ``
+}
+
 func Ignore(_ interface{}) interface{} {
 	return nil
 }
@@ -17,3 +17,9 @@ func TestNumberFormat(t *testing.T) {
 	assert.Equal(t, "12,234,567", NumberTrim(12234567.987, 0))
 	assert.Equal(t, "234567.9", NumberTrim(234567.987, 1, ``))
 }
+
+func TestCSPNonce(t *testing.T) {
+	fn, ok := New()[`CSPNonce`].(func() string)
+	assert.True(t, ok)
+	assert.Equal(t, ``, fn())
+}
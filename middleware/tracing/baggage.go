@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxBaggageMembers and maxBaggageBytes are the limits of the W3C Baggage specification.
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+// Member is a member of the baggage.
+type Member struct {
+	Value      string
+	Properties string // the properties after the value, e.g. `p1;p2=v`, passed through unchanged
+}
+
+// Baggage is the set of the user-defined key-value pairs propagated with the trace context.
+type Baggage map[string]Member
+
+// ParseBaggage parses the values of the baggage headers. Invalid members are ignored.
+func ParseBaggage(values ...string) Baggage {
+	b := Baggage{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, `,`) {
+			if len(b) >= maxBaggageMembers {
+				return b
+			}
+			item = strings.TrimSpace(item)
+			if len(item) == 0 {
+				continue
+			}
+			var props string
+			if pos := strings.Index(item, `;`); pos > -1 {
+				props = strings.TrimSpace(item[pos+1:])
+				item = item[:pos]
+			}
+			key, val, ok := strings.Cut(item, `=`)
+			if !ok {
+				continue
+			}
+			key = strings.TrimSpace(key)
+			if len(key) == 0 {
+				continue
+			}
+			val, err := url.PathUnescape(strings.TrimSpace(val))
+			if err != nil {
+				continue
+			}
+			b[key] = Member{Value: val, Properties: props}
+		}
+	}
+	return b
+}
+
+// Get returns the value of the key.
+func (b Baggage) Get(key string) string {
+	return b[key].Value
+}
+
+// Set sets the value of the key.
+func (b Baggage) Set(key string, value string) {
+	b[key] = Member{Value: value}
+}
+
+// Clone returns a copy of the baggage, which can be modified safely.
+func (b Baggage) Clone() Baggage {
+	r := make(Baggage, len(b))
+	for k, v := range b {
+		r[k] = v
+	}
+	return r
+}
+
+// String returns the value of the baggage header sorted by key.
+// The members exceeding the size limit are dropped.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for key := range b {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, key := range keys {
+		m := b[key]
+		item := key + `=` + url.PathEscape(m.Value)
+		if len(m.Properties) > 0 {
+			item += `;` + m.Properties
+		}
+		if sb.Len()+len(item)+1 > maxBaggageBytes {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(item)
+	}
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Extract returns the span context of the traceparent and tracestate headers, and the baggage
+// of the baggage headers. The span context is invalid if the traceparent header is missing or invalid.
+func Extract(header http.Header) (SpanContext, Baggage) {
+	var b Baggage
+	if values := header.Values(HeaderBaggage); len(values) > 0 {
+		b = ParseBaggage(values...)
+	}
+	sc, err := ParseTraceparent(header.Get(HeaderTraceparent))
+	if err != nil {
+		return SpanContext{}, b
+	}
+	sc.TraceState = strings.Join(header.Values(HeaderTracestate), `,`)
+	sc.Remote = true
+	return sc, b
+}
+
+// ExtractContext returns a copy of ctx containing the remote span context and the baggage of the headers.
+func ExtractContext(ctx context.Context, header http.Header) context.Context {
+	sc, b := Extract(header)
+	if sc.IsValid() {
+		ctx = ContextWithRemoteSpanContext(ctx, sc)
+	}
+	if len(b) > 0 {
+		ctx = ContextWithBaggage(ctx, b)
+	}
+	return ctx
+}
+
+// Inject sets the traceparent, tracestate and baggage headers from the span context
+// and the baggage in ctx.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if sc.IsValid() {
+		header.Set(HeaderTraceparent, sc.Traceparent())
+		if len(sc.TraceState) > 0 {
+			header.Set(HeaderTracestate, sc.TraceState)
+		} else {
+			header.Del(HeaderTracestate)
+		}
+	}
+	if b := BaggageFromContext(ctx); len(b) > 0 {
+		header.Set(HeaderBaggage, b.String())
+	}
+}
+
+// Transport is a `http.RoundTripper` which starts a client span for every request
+// and injects its context into the outbound headers.
+type Transport struct {
+	Tracer *Tracer
+	// Base is the underlying transport.
+	// Optional. Default value `http.DefaultTransport`.
+	Base http.RoundTripper
+}
+
+func NewTransport(tracer *Tracer, base ...http.RoundTripper) *Transport {
+	t := &Transport{Tracer: tracer}
+	if len(base) > 0 {
+		t.Base = base[0]
+	}
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx, span := t.Tracer.Start(req.Context(), `HTTP `+req.Method, SpanKindClient)
+	req = req.Clone(ctx) // a RoundTripper must not modify the request
+	Inject(ctx, req.Header)
+	span.SetAttribute(`http.request.method`, req.Method)
+	span.SetAttribute(`url.full`, req.URL.String())
+	span.SetAttribute(`server.address`, req.URL.Hostname())
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return resp, err
+	}
+	span.SetAttribute(`http.response.status_code`, resp.StatusCode)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(StatusError, strconv.Itoa(resp.StatusCode)+` `+http.StatusText(resp.StatusCode))
+	}
+	span.End()
+	return resp, nil
+}
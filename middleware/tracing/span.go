@@ -0,0 +1,285 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SpanKind is the role of a span in a trace.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota
+	SpanKindServer
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return `server`
+	case SpanKindClient:
+		return `client`
+	default:
+		return `internal`
+	}
+}
+
+// StatusCode is the status of a span.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Event is a timestamped annotation of a span, e.g. a recorded error.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]interface{}
+}
+
+// Span is a timed operation of a trace. It is exported by the `Exporter` of the tracer when it ends
+// if it is sampled.
+type Span struct {
+	Name              string
+	Kind              SpanKind
+	SpanContext       SpanContext
+	Parent            SpanContext
+	StartTime         time.Time
+	EndTime           time.Time
+	Attributes        map[string]interface{}
+	Events            []Event
+	Status            StatusCode
+	StatusDescription string
+
+	tracer *Tracer
+	mutex  sync.Mutex
+	ended  bool
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mutex.Lock()
+	if s.Attributes == nil {
+		s.Attributes = map[string]interface{}{}
+	}
+	s.Attributes[key] = value
+	s.mutex.Unlock()
+}
+
+// AddEvent adds an event to the span.
+func (s *Span) AddEvent(name string, attributes map[string]interface{}) {
+	s.mutex.Lock()
+	s.Events = append(s.Events, Event{Name: name, Time: time.Now(), Attributes: attributes})
+	s.mutex.Unlock()
+}
+
+// RecordError adds an `exception` event of the error and sets the status to `StatusError`.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.AddEvent(`exception`, map[string]interface{}{`exception.message`: err.Error()})
+	s.SetStatus(StatusError, err.Error())
+}
+
+// SetStatus sets the status of the span. `StatusOK` is final and can not be changed.
+func (s *Span) SetStatus(code StatusCode, description string) {
+	s.mutex.Lock()
+	if s.Status != StatusOK {
+		s.Status = code
+		if code == StatusError {
+			s.StatusDescription = description
+		} else {
+			s.StatusDescription = ``
+		}
+	}
+	s.mutex.Unlock()
+}
+
+// End ends the span and exports it. Only the first call has effect.
+func (s *Span) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mutex.Unlock()
+	if s.tracer != nil && s.SpanContext.IsSampled() {
+		s.tracer.export(s)
+	}
+}
+
+// Duration returns the duration of an ended span.
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Exporter exports the ended spans, e.g. to a collector.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+}
+
+// ExporterFunc is a function used as `Exporter`.
+type ExporterFunc func(ctx context.Context, spans []*Span) error
+
+func (f ExporterFunc) ExportSpans(ctx context.Context, spans []*Span) error {
+	return f(ctx, spans)
+}
+
+// InMemoryExporter keeps the exported spans in memory. It is useful for tests.
+type InMemoryExporter struct {
+	mutex sync.Mutex
+	spans []*Span
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) ExportSpans(_ context.Context, spans []*Span) error {
+	e.mutex.Lock()
+	e.spans = append(e.spans, spans...)
+	e.mutex.Unlock()
+	return nil
+}
+
+// Spans returns the exported spans in the order they ended.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+func (e *InMemoryExporter) Reset() {
+	e.mutex.Lock()
+	e.spans = nil
+	e.mutex.Unlock()
+}
+
+// Sampler decides whether a new trace is sampled. The spans with a valid parent follow
+// the sampled flag of the parent.
+type Sampler func(traceID TraceID, name string) bool
+
+// AlwaysSample samples all the new traces.
+func AlwaysSample(TraceID, string) bool {
+	return true
+}
+
+// NeverSample samples none of the new traces. The context is still propagated.
+func NeverSample(TraceID, string) bool {
+	return false
+}
+
+// Tracer creates the spans and sends the ended ones to the exporter.
+type Tracer struct {
+	Exporter Exporter
+	Sampler  Sampler
+
+	// OnError is called when the exporter fails.
+	// Optional. Default ignores the error.
+	OnError func(error)
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{Exporter: exporter, Sampler: AlwaysSample}
+}
+
+// Start starts a span which is the child of the span or the remote span context in ctx,
+// and returns the context containing the new span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	var parent SpanContext
+	if sp := SpanFromContext(ctx); sp != nil {
+		parent = sp.SpanContext
+	} else {
+		parent = RemoteSpanContextFromContext(ctx)
+	}
+	sc := SpanContext{SpanID: NewSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = NewTraceID()
+		sampler := t.Sampler
+		if sampler == nil {
+			sampler = AlwaysSample
+		}
+		if sampler(sc.TraceID, name) {
+			sc.Flags |= FlagsSampled
+		}
+	}
+	span := &Span{
+		Name:        name,
+		Kind:        kind,
+		SpanContext: sc,
+		Parent:      parent,
+		StartTime:   time.Now(),
+		tracer:      t,
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) export(span *Span) {
+	if t.Exporter == nil {
+		return
+	}
+	if err := t.Exporter.ExportSpans(context.Background(), []*Span{span}); err != nil && t.OnError != nil {
+		t.OnError(err)
+	}
+}
+
+type (
+	spanKey       struct{}
+	remoteSpanKey struct{}
+	baggageKey    struct{}
+)
+
+// ContextWithSpan returns a copy of ctx containing the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the current span in ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a copy of ctx containing the span context extracted
+// from an incoming request, which becomes the parent of the next span.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+func RemoteSpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(remoteSpanKey{}).(SpanContext)
+	return sc
+}
+
+// SpanContextFromContext returns the span context of the current span, or the remote span context.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext
+	}
+	return RemoteSpanContextFromContext(ctx)
+}
+
+// ContextWithBaggage returns a copy of ctx containing the baggage.
+func ContextWithBaggage(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// BaggageFromContext returns the baggage in ctx, or nil.
+// Use `Baggage.Clone()` before modifying it.
+func BaggageFromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	return b
+}
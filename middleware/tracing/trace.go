@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	HeaderTraceparent = `traceparent`
+	HeaderTracestate  = `tracestate`
+	HeaderBaggage     = `baggage`
+
+	// FlagsSampled is the sampled flag of the trace flags.
+	FlagsSampled byte = 0x01
+)
+
+var ErrInvalidTraceparent = errors.New(`invalid traceparent`)
+
+type (
+	// TraceID is the 16 bytes identifier of a trace.
+	TraceID [16]byte
+	// SpanID is the 8 bytes identifier of a span.
+	SpanID [8]byte
+)
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// NewTraceID returns a random trace ID.
+func NewTraceID() (id TraceID) {
+	rand.Read(id[:])
+	return
+}
+
+// NewSpanID returns a random span ID.
+func NewSpanID() (id SpanID) {
+	rand.Read(id[:])
+	return
+}
+
+// SpanContext is the part of a span which is propagated to other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string // the value of the tracestate header, passed through unchanged
+	Remote     bool   // extracted from an incoming request
+}
+
+func (s SpanContext) IsValid() bool {
+	return s.TraceID.IsValid() && s.SpanID.IsValid()
+}
+
+func (s SpanContext) IsSampled() bool {
+	return s.Flags&FlagsSampled == FlagsSampled
+}
+
+// Traceparent returns the value of the traceparent header (version 00).
+func (s SpanContext) Traceparent() string {
+	return `00-` + s.TraceID.String() + `-` + s.SpanID.String() + `-` + hex.EncodeToString([]byte{s.Flags})
+}
+
+// ParseTraceparent parses the value of the traceparent header.
+// Versions after 00 are parsed as 00 as required by the specification.
+func ParseTraceparent(value string) (sc SpanContext, err error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || (len(value) > 55 && value[55] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	version, err := hex.DecodeString(value[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) {
+		return sc, ErrInvalidTraceparent
+	}
+	if !isLowerHex(value[:55]) {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err = hex.Decode(sc.TraceID[:], []byte(value[3:35])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	if _, err = hex.Decode(sc.SpanID[:], []byte(value[36:52])); err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	flags, err := hex.DecodeString(value[53:55])
+	if err != nil {
+		return sc, ErrInvalidTraceparent
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware"
+)
+
+type (
+	// TraceConfig defines the config for Trace middleware.
+	TraceConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper echo.Skipper
+
+		// Tracer creates the server spans.
+		// Required.
+		Tracer *Tracer
+
+		// SpanName returns the name of the server span.
+		// Optional. Default value is the method and the route path, e.g. `GET /users/:id`.
+		SpanName func(echo.Context) string
+
+		// Untrusted ignores the trace context of the incoming requests, so that a new trace
+		// is started for every request, e.g. for public endpoints.
+		Untrusted bool
+
+		// DisableInject disables the client spans of the requests forwarded by the `Proxy`
+		// middleware, whose context is injected into the outbound headers.
+		DisableInject bool
+	}
+)
+
+var (
+	// DefaultTraceConfig is the default Trace middleware config.
+	DefaultTraceConfig = TraceConfig{
+		Skipper: echo.DefaultSkipper,
+	}
+	ErrTracerRequired = errors.New(`echo: tracing middleware requires a tracer`)
+)
+
+// Trace returns a Trace middleware.
+//
+// Trace middleware starts a server span for every request, which continues the trace of the
+// W3C Trace Context headers. Like `middleware.Log`, the error of the handler is handled
+// by `c.Error()` in the middleware, so that the status written by the `HTTPErrorHandler`
+// is recorded.
+func Trace(tracer *Tracer) echo.MiddlewareFunc {
+	c := DefaultTraceConfig
+	c.Tracer = tracer
+	return TraceWithConfig(c)
+}
+
+// TraceWithConfig returns a Trace middleware with config.
+// See: `Trace()`.
+func TraceWithConfig(config TraceConfig) echo.MiddlewareFunc {
+	if config.Tracer == nil {
+		panic(ErrTracerRequired)
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultTraceConfig.Skipper
+	}
+	if config.SpanName == nil {
+		config.SpanName = DefaultSpanName
+	}
+
+	return func(h echo.Handler) echo.Handler {
+		return echo.HandlerFunc(func(c echo.Context) error {
+			if config.Skipper(c) {
+				return h.Handle(c)
+			}
+
+			req := c.Request()
+			ctx := c.StdContext()
+			if !config.Untrusted {
+				ctx = ExtractContext(ctx, req.Header().Std())
+			}
+			ctx, span := config.Tracer.Start(ctx, config.SpanName(c), SpanKindServer)
+			span.SetAttribute(`http.request.method`, req.Method())
+			span.SetAttribute(`url.path`, req.URL().Path())
+			span.SetAttribute(`url.scheme`, req.Scheme())
+			span.SetAttribute(`server.address`, req.Host())
+			span.SetAttribute(`client.address`, c.RealIP())
+			span.SetAttribute(`user_agent.original`, req.UserAgent())
+			if route := c.Route().Path; len(route) > 0 {
+				span.SetAttribute(`http.route`, route)
+			}
+
+			c.Internal().Set(contextKey, ctx)
+			*req.StdRequest() = *c.WithContext(ctx)
+			if !config.DisableInject {
+				middleware.SetProxyTransport(c, NewTransport(config.Tracer, middleware.ProxyTransport(c)))
+			}
+
+			if err := h.Handle(c); err != nil {
+				span.AddEvent(`exception`, map[string]interface{}{`exception.message`: err.Error()})
+				c.Error(err)
+			}
+
+			status := c.Response().Status()
+			span.SetAttribute(`http.response.status_code`, status)
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(StatusError, http.StatusText(status))
+			}
+			span.End()
+			return nil
+		})
+	}
+}
+
+const contextKey = `tracing.context`
+
+// Context returns the context containing the server span of the request. Unlike
+// `c.StdContext()`, which is the `fasthttp.RequestCtx` on the fasthttp engine,
+// it works on both engines.
+func Context(c echo.Context) context.Context {
+	if ctx, ok := c.Internal().Get(contextKey).(context.Context); ok {
+		return ctx
+	}
+	return c.StdContext()
+}
+
+// SpanFromEchoContext returns the server span of the request, or nil.
+func SpanFromEchoContext(c echo.Context) *Span {
+	return SpanFromContext(Context(c))
+}
+
+// DefaultSpanName returns the method and the route path of the request, e.g. `GET /users/:id`.
+// The route path is used instead of the request path so that the number of span names stays bounded.
+func DefaultSpanName(c echo.Context) string {
+	method := c.Request().Method()
+	if route := c.Route().Path; len(route) > 0 {
+		return method + ` ` + route
+	}
+	return method
+}
@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/middleware"
+	te "github.com/webx-top/echo/testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent(`00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`)
+	assert.NoError(t, err)
+	assert.Equal(t, `4bf92f3577b34da6a3ce929d0e0e4736`, sc.TraceID.String())
+	assert.Equal(t, `00f067aa0ba902b7`, sc.SpanID.String())
+	assert.True(t, sc.IsSampled())
+	assert.Equal(t, `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`, sc.Traceparent())
+
+	// future versions may have more fields
+	_, err = ParseTraceparent(`01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra`)
+	assert.NoError(t, err)
+
+	for _, value := range []string{
+		``,
+		`00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra`,
+		`ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`,
+		`00-00000000000000000000000000000000-00f067aa0ba902b7-01`,
+		`00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01`,
+		`00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01`,
+	} {
+		_, err = ParseTraceparent(value)
+		assert.Equal(t, ErrInvalidTraceparent, err, value)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	b := ParseBaggage(`userId=alice, serverNode=DF%2028;p1, bad`, `isProduction=false`)
+	assert.Equal(t, `alice`, b.Get(`userId`))
+	assert.Equal(t, `DF 28`, b.Get(`serverNode`))
+	assert.Equal(t, `p1`, b[`serverNode`].Properties)
+	assert.Len(t, b, 3)
+	assert.Equal(t, `isProduction=false,serverNode=DF%2028;p1,userId=alice`, b.String())
+}
+
+func TestTrace(t *testing.T) {
+	var outbound http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Clone()
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+
+	exporter := NewInMemoryExporter()
+	e := echo.New()
+	e.Use(Trace(NewTracer(exporter)))
+	e.Get(`/users/:id`, func(c echo.Context) error {
+		span := SpanFromEchoContext(c)
+		span.SetAttribute(`user.id`, c.Param(`id`))
+		return c.String(BaggageFromContext(Context(c)).Get(`tenant`))
+	})
+	e.Get(`/fail`, func(c echo.Context) error {
+		return errors.New(`boom`)
+	})
+	e.Get(`/header`, func(c echo.Context) error {
+		return c.String(c.Header(HeaderTraceparent))
+	})
+	e.Get(`/proxy`, func(c echo.Context) error {
+		return nil
+	}, middleware.Proxy(middleware.NewRandomBalancer([]middleware.ProxyTargeter{&middleware.ProxyTarget{URL: target}})))
+	e.Commit()
+
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(te.WrapRequest(req), te.WrapResponse(req, rec))
+		return rec
+	}
+
+	// continues the incoming trace
+	rec := serve(`/users/1`, http.Header{
+		`Traceparent`: {`00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`},
+		`Tracestate`:  {`vendor=value`},
+		`Baggage`:     {`tenant=acme`},
+	})
+	assert.Equal(t, `acme`, rec.Body.String())
+	spans := exporter.Spans()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, `GET /users/:id`, span.Name)
+	assert.Equal(t, SpanKindServer, span.Kind)
+	assert.Equal(t, `4bf92f3577b34da6a3ce929d0e0e4736`, span.SpanContext.TraceID.String())
+	assert.Equal(t, `00f067aa0ba902b7`, span.Parent.SpanID.String())
+	assert.True(t, span.Parent.Remote)
+	assert.Equal(t, `vendor=value`, span.SpanContext.TraceState)
+	assert.Equal(t, `/users/:id`, span.Attributes[`http.route`])
+	assert.Equal(t, `1`, span.Attributes[`user.id`])
+	assert.Equal(t, http.StatusOK, span.Attributes[`http.response.status_code`])
+	assert.Equal(t, StatusUnset, span.Status)
+
+	// records the status written by the HTTPErrorHandler
+	exporter.Reset()
+	serve(`/fail`, nil)
+	span = exporter.Spans()[0]
+	assert.False(t, span.Parent.IsValid())
+	assert.Equal(t, http.StatusInternalServerError, span.Attributes[`http.response.status_code`])
+	assert.Equal(t, StatusError, span.Status)
+	assert.Equal(t, `exception`, span.Events[0].Name)
+	assert.Equal(t, `boom`, span.Events[0].Attributes[`exception.message`])
+
+	exporter.Reset()
+	serve(`/none`, nil)
+	span = exporter.Spans()[0]
+	assert.Equal(t, `GET`, span.Name)
+	assert.Equal(t, http.StatusNotFound, span.Attributes[`http.response.status_code`])
+	assert.Equal(t, StatusUnset, span.Status)
+
+	// the headers of the incoming request are kept
+	incoming := `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`
+	rec = serve(`/header`, http.Header{`Traceparent`: {incoming}})
+	assert.Equal(t, incoming, rec.Body.String())
+
+	// injects the context of a client span into the proxied request
+	exporter.Reset()
+	serve(`/proxy`, http.Header{`Traceparent`: {incoming}, `Baggage`: {`tenant=acme`}})
+	spans = exporter.Spans()
+	if assert.Len(t, spans, 2) {
+		client, server := spans[0], spans[1]
+		assert.Equal(t, SpanKindClient, client.Kind)
+		assert.Equal(t, SpanKindServer, server.Kind)
+		assert.Equal(t, server.SpanContext.SpanID, client.Parent.SpanID)
+		assert.Equal(t, client.SpanContext.Traceparent(), outbound.Get(HeaderTraceparent))
+	}
+	assert.Equal(t, `tenant=acme`, outbound.Get(HeaderBaggage))
+}
+
+func TestTransport(t *testing.T) {
+	var traceparent string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(HeaderTraceparent)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer backend.Close()
+
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+	ctx, parent := tracer.Start(context.Background(), `parent`, SpanKindInternal)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL, nil)
+	resp, err := (&http.Client{Transport: NewTransport(tracer)}).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	parent.End()
+
+	spans := exporter.Spans()
+	assert.Len(t, spans, 2)
+	client := spans[0]
+	assert.Equal(t, SpanKindClient, client.Kind)
+	assert.Equal(t, parent.SpanContext.SpanID, client.Parent.SpanID)
+	assert.Equal(t, client.SpanContext.Traceparent(), traceparent)
+	assert.Equal(t, StatusError, client.Status)
+	assert.Empty(t, req.Header.Get(HeaderTraceparent))
+
+	// unsampled spans are propagated but not exported
+	exporter.Reset()
+	tracer.Sampler = NeverSample
+	_, span := tracer.Start(context.Background(), `unsampled`, SpanKindInternal)
+	span.End()
+	assert.False(t, span.SpanContext.IsSampled())
+	assert.Empty(t, exporter.Spans())
+}
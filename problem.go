@@ -0,0 +1,372 @@
+package echo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/webx-top/com"
+
+	pkgCode "github.com/webx-top/echo/code"
+)
+
+// ProblemNamespace is the XML namespace of the problem details.
+const ProblemNamespace = `urn:ietf:rfc:7807`
+
+// Problem is the problem details of an error response (RFC 9457).
+type Problem struct {
+	Type     string              `json:"type,omitempty"`
+	Title    string              `json:"title,omitempty"`
+	Status   int                 `json:"status,omitempty"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     int                 `json:"code,omitempty"` // the `code.Code` of `*echo.Error`, omitted for `code.Failure`
+	Zone     string              `json:"zone,omitempty"`
+	Errors   []ProblemFieldError `json:"errors,omitempty"`
+
+	// Extensions are the extension members, e.g. the `Extra` of `*echo.Error`.
+	// They can not override the members above.
+	Extensions H `json:"-"`
+}
+
+// ProblemFieldError is an invalid field of a validation problem.
+type ProblemFieldError struct {
+	Field  string `json:"field" xml:"field"`
+	Rule   string `json:"rule,omitempty" xml:"rule,omitempty"`
+	Detail string `json:"detail" xml:"detail"`
+}
+
+func (p *Problem) members() H {
+	m := H{}
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	set := func(key string, value interface{}, ok bool) {
+		if ok {
+			m[key] = value
+		} else {
+			delete(m, key)
+		}
+	}
+	set(`type`, p.Type, len(p.Type) > 0)
+	set(`title`, p.Title, len(p.Title) > 0)
+	set(`status`, p.Status, p.Status != 0)
+	set(`detail`, p.Detail, len(p.Detail) > 0)
+	set(`instance`, p.Instance, len(p.Instance) > 0)
+	set(`code`, p.Code, p.Code != 0)
+	set(`zone`, p.Zone, len(p.Zone) > 0)
+	set(`errors`, p.Errors, len(p.Errors) > 0)
+	return m
+}
+
+// MarshalJSON encodes the members and the extensions into one object.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+// MarshalXML encodes the problem as described in the appendix of RFC 9457.
+// The extensions are encoded with `fmt.Sprint` except the slices and maps.
+func (p *Problem) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: ProblemNamespace, Local: `problem`}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	m := p.members()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := encodeProblemXML(enc, k, m[k]); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeProblemXML(enc *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v := value.(type) {
+	case []ProblemFieldError:
+		return enc.EncodeElement(struct {
+			Items []ProblemFieldError `xml:"i"`
+		}{Items: v}, start)
+	case H:
+		return encodeProblemXML(enc, name, map[string]interface{}(v))
+	case map[string]interface{}:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeProblemXML(enc, k, v[k]); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []interface{}:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := encodeProblemXML(enc, `i`, item); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []string:
+		return enc.EncodeElement(struct {
+			Items []string `xml:"i"`
+		}{Items: v}, start)
+	case nil:
+		return enc.EncodeElement(``, start)
+	}
+	return enc.EncodeElement(fmt.Sprint(value), start)
+}
+
+// ProblemProvider is implemented by the errors which provide their own problem details.
+type ProblemProvider interface {
+	Problem() *Problem
+}
+
+// ProblemType is the problem type of a `code.Code`.
+type ProblemType struct {
+	URI    string // the `type` member, e.g. `https://example.com/problems/out-of-credit`
+	Title  string // optional, default `code.Code.String()`
+	Status int    // optional, default `code.Code.HTTPCode()`
+}
+
+// ProblemTypes maps the `code.Code` values to the problem types.
+type ProblemTypes struct {
+	mutex sync.RWMutex
+	types map[pkgCode.Code]ProblemType
+}
+
+func NewProblemTypes() *ProblemTypes {
+	return &ProblemTypes{types: map[pkgCode.Code]ProblemType{}}
+}
+
+// DefaultProblemTypes is the registry used by default.
+var DefaultProblemTypes = NewProblemTypes()
+
+func (p *ProblemTypes) Register(code pkgCode.Code, typ ProblemType) *ProblemTypes {
+	p.mutex.Lock()
+	p.types[code] = typ
+	p.mutex.Unlock()
+	return p
+}
+
+func (p *ProblemTypes) Unregister(code pkgCode.Code) *ProblemTypes {
+	p.mutex.Lock()
+	delete(p.types, code)
+	p.mutex.Unlock()
+	return p
+}
+
+func (p *ProblemTypes) Get(code pkgCode.Code) (ProblemType, bool) {
+	p.mutex.RLock()
+	typ, ok := p.types[code]
+	p.mutex.RUnlock()
+	return typ, ok
+}
+
+// ProblemConfig is the config of `ProblemHTTPErrorHandler`.
+type ProblemConfig struct {
+	// Types maps the `code.Code` of `*echo.Error` to the problem types.
+	// Optional. Default value `DefaultProblemTypes`.
+	Types *ProblemTypes
+
+	// ValidationStatus is the status of the validation errors.
+	// Optional. Default value 422.
+	ValidationStatus int
+
+	// Instance returns the `instance` member.
+	// Optional. Default omits it.
+	Instance func(Context) string
+
+	// Rewrite modifies the problem before it is written, e.g. to add extensions.
+	// Optional.
+	Rewrite func(Context, error, *Problem)
+}
+
+// DefaultProblemConfig is the default config of `ProblemHTTPErrorHandler`.
+var DefaultProblemConfig = ProblemConfig{
+	Types:            DefaultProblemTypes,
+	ValidationStatus: http.StatusUnprocessableEntity,
+}
+
+func (config *ProblemConfig) setDefaults() {
+	if config.Types == nil {
+		config.Types = DefaultProblemConfig.Types
+	}
+	if config.ValidationStatus == 0 {
+		config.ValidationStatus = DefaultProblemConfig.ValidationStatus
+	}
+}
+
+// Problem returns the problem details of the error.
+// The details of unknown errors are hidden unless the debug mode is enabled.
+func (config *ProblemConfig) Problem(c Context, err error) *Problem {
+	config.setDefaults()
+	var p *Problem
+	var provider ProblemProvider
+	var vr ValidateResult
+	var he *HTTPError
+	var ee *Error
+	switch {
+	case errors.As(err, &provider):
+		p = provider.Problem()
+	case errors.As(err, &ee):
+		p = &Problem{Status: ee.Code.HTTPCode(), Detail: ee.Message, Code: ee.Code.Int(), Zone: ee.Zone}
+		if len(ee.Extra) > 0 {
+			p.Extensions = ee.Extra.Clone()
+		}
+		if typ, ok := config.Types.Get(ee.Code); ok {
+			p.Type = typ.URI
+			p.Title = typ.Title
+			if len(p.Title) == 0 {
+				p.Title = ee.Code.String()
+			}
+			if typ.Status > 0 {
+				p.Status = typ.Status
+			}
+		}
+	case errors.As(err, &he):
+		p = &Problem{Status: he.Code, Detail: he.Message}
+	case (errors.As(err, &vr) && !vr.Ok()) || len(FieldErrorsOf(err)) > 0:
+		p = &Problem{Status: config.ValidationStatus, Detail: err.Error()}
+	default:
+		p = &Problem{Status: http.StatusInternalServerError}
+		if c.Echo().Debug() {
+			p.Detail = err.Error()
+		}
+	}
+	if len(p.Errors) == 0 {
+		for _, fe := range FieldErrorsOf(err) {
+			p.Errors = append(p.Errors, ProblemFieldError{Field: fe.Field, Rule: fe.Rule, Detail: fe.Message})
+		}
+	}
+	if p.Status < 100 {
+		p.Status = http.StatusInternalServerError
+	}
+	if len(p.Type) == 0 {
+		p.Type = `about:blank`
+	}
+	if len(p.Title) == 0 {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Detail == p.Title {
+		p.Detail = ``
+	}
+	if len(p.Instance) == 0 && config.Instance != nil {
+		p.Instance = config.Instance(c)
+	}
+	if config.Rewrite != nil {
+		config.Rewrite(c, err, p)
+	}
+	return p
+}
+
+// ProblemHTTPErrorHandler returns a `HTTPErrorHandler` which writes the errors as problem details
+// in `application/problem+json` or `application/problem+xml` according to the Accept header.
+//
+//	e.SetHTTPErrorHandler(echo.ProblemHTTPErrorHandler())
+func ProblemHTTPErrorHandler(config ...ProblemConfig) HTTPErrorHandler {
+	cfg := DefaultProblemConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	cfg.setDefaults()
+	return func(err error, c Context) {
+		defer c.Logger().Debug(err, `: `, c.Request().URL().String())
+		if c.Response().Committed() {
+			return
+		}
+		p := cfg.Problem(c, err)
+		if c.Request().Method() == HEAD {
+			c.NoContent(p.Status)
+			return
+		}
+		var (
+			b           []byte
+			contentType string
+			encErr      error
+		)
+		if AcceptsProblemXML(c) {
+			contentType = MIMEApplicationProblemXML + `; ` + CharsetUTF8
+			b, encErr = xml.Marshal(p)
+			b = append([]byte(xml.Header), b...)
+		} else {
+			contentType = MIMEApplicationProblemJSON
+			b, encErr = json.Marshal(p)
+		}
+		if encErr != nil {
+			c.Logger().Error(encErr)
+			c.String(http.StatusText(p.Status), p.Status)
+			return
+		}
+		c.Response().Header().Set(HeaderContentType, contentType)
+		c.Blob(b, p.Status)
+	}
+}
+
+// AcceptsProblemXML reports whether the client prefers XML to JSON.
+// The Accept header is parsed by `Accepts#Advance` and the quality of each format is
+// the one of the most specific media range matching it, i.e. `application/problem+xml`
+// before `application/xml` (and the other `+xml` types) before `application/*` before `*/*`.
+// JSON is preferred when the qualities are equal.
+// A media range without q parameter has the quality 0 in `Accepts`, which is regarded as 1.
+func AcceptsProblemXML(c Context) bool {
+	accepts := NewAccepts(c.Header(HeaderAccept)).Advance()
+	return problemQuality(accepts, MIMEApplicationProblemXML, MIMEApplicationXML, `text/xml`) >
+		problemQuality(accepts, MIMEApplicationProblemJSON, MIMEApplicationJSON)
+}
+
+// problemQuality returns the quality of the most specific media range matching the types,
+// or -1 if none matches. The first type is the exact one and the others are equivalent.
+func problemQuality(accepts *Accepts, types ...string) float32 {
+	var (
+		quality     float32 = -1
+		specificity         = -1
+	)
+	for _, aq := range accepts.Accepts {
+		q := aq.Quality
+		if q <= 0 {
+			q = 1
+		}
+		for _, a := range aq.Type {
+			var s int
+			switch {
+			case strings.EqualFold(a.Raw, types[0]):
+				s = 4
+			case com.InSlice(strings.ToLower(a.Raw), types[1:]):
+				s = 3
+			case com.InSlice(strings.ToLower(a.Mime), types):
+				s = 2
+			case a.Raw == `application/*`:
+				s = 1
+			case a.Raw == `*/*`:
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				quality, specificity = q, s
+			}
+		}
+	}
+	return quality
+}
@@ -0,0 +1,102 @@
+package echo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/webx-top/echo"
+	"github.com/webx-top/echo/code"
+	test "github.com/webx-top/echo/testing"
+)
+
+func TestProblemHTTPErrorHandler(t *testing.T) {
+	types := NewProblemTypes().Register(code.DataNotFound, ProblemType{URI: `https://example.com/problems/not-found`})
+	e := New()
+	e.SetHTTPErrorHandler(ProblemHTTPErrorHandler(ProblemConfig{
+		Types:    types,
+		Instance: func(c Context) string { return c.Request().URL().Path() },
+	}))
+	e.Get(`/http`, func(c Context) error {
+		return NewHTTPError(http.StatusForbidden, `no access`)
+	})
+	e.Get(`/code`, func(c Context) error {
+		return NewError(`user not found`, code.DataNotFound).SetZone(`id`).Set(`id`, 7)
+	})
+	e.Get(`/valid`, func(c Context) error {
+		return NewValidateResult().SetError(errors.New(`required`)).SetField(`name`).AsError()
+	})
+	e.Get(`/plain`, func(c Context) error {
+		return errors.New(`secret`)
+	})
+	e.Commit()
+
+	serve := func(path string, accept string) (int, string, string) {
+		rec := test.Request(http.MethodGet, path, e, func(req *http.Request) {
+			if len(accept) > 0 {
+				req.Header.Set(HeaderAccept, accept)
+			}
+		})
+		return rec.Code, rec.Header().Get(HeaderContentType), rec.Body.String()
+	}
+	decode := func(body string) H {
+		m := H{}
+		assert.NoError(t, json.Unmarshal([]byte(body), &m))
+		return m
+	}
+
+	status, contentType, body := serve(`/http`, ``)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, MIMEApplicationProblemJSON, contentType)
+	assert.Equal(t, H{`type`: `about:blank`, `title`: `Forbidden`, `status`: float64(403), `detail`: `no access`, `instance`: `/http`}, decode(body))
+
+	status, _, body = serve(`/code`, `application/json`)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, H{
+		`type`:     `https://example.com/problems/not-found`,
+		`title`:    `DataNotFound`,
+		`status`:   float64(404),
+		`detail`:   `user not found`,
+		`instance`: `/code`,
+		`code`:     float64(code.DataNotFound),
+		`zone`:     `id`,
+		`id`:       float64(7),
+	}, decode(body))
+
+	status, _, body = serve(`/valid`, ``)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, []interface{}{map[string]interface{}{`field`: `name`, `detail`: `required`}}, decode(body)[`errors`])
+
+	status, _, body = serve(`/plain`, ``)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.NotContains(t, body, `secret`)
+
+	status, contentType, body = serve(`/code`, `application/problem+xml`)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.True(t, strings.HasPrefix(contentType, MIMEApplicationProblemXML))
+	assert.Contains(t, body, `<problem xmlns="urn:ietf:rfc:7807">`)
+	assert.Contains(t, body, `<code>-100</code>`)
+	assert.Contains(t, body, `<id>7</id>`)
+	assert.Contains(t, body, `<type>https://example.com/problems/not-found</type>`)
+
+	_, _, body = serve(`/valid`, `text/xml`)
+	assert.Contains(t, body, `<errors><i><field>name</field><detail>required</detail></i></errors>`)
+
+	_, contentType, _ = serve(`/http`, `application/problem+xml;q=0, application/json`)
+	assert.Equal(t, MIMEApplicationProblemJSON, contentType)
+
+	_, contentType, _ = serve(`/http`, `application/json;q=0.5, text/xml`)
+	assert.True(t, strings.HasPrefix(contentType, MIMEApplicationProblemXML))
+
+	// the most specific media range decides the quality
+	_, contentType, _ = serve(`/http`, `*/*;q=0.8, application/json;q=0.1, application/xml;q=0.5`)
+	assert.True(t, strings.HasPrefix(contentType, MIMEApplicationProblemXML))
+	_, contentType, _ = serve(`/http`, `application/problem+json;q=0.2, application/*;q=1, application/problem+xml;q=0.9`)
+	assert.True(t, strings.HasPrefix(contentType, MIMEApplicationProblemXML))
+	_, contentType, _ = serve(`/http`, `application/*;q=1, application/problem+xml;q=0.9`)
+	assert.Equal(t, MIMEApplicationProblemJSON, contentType)
+}
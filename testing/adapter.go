@@ -0,0 +1,70 @@
+package testing
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/admpub/fasthttp"
+
+	"github.com/webx-top/echo/engine"
+	fasthttpEngine "github.com/webx-top/echo/engine/fasthttp"
+)
+
+// Adapter serves the request with the handler through the request and response adapters of an engine.
+type Adapter func(handler engine.Handler, req *http.Request) *http.Response
+
+// StandardAdapter serves the request through the adapters of the standard engine.
+func StandardAdapter(handler engine.Handler, req *http.Request) *http.Response {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(WrapRequest(req), WrapResponse(req, rec))
+	resp := rec.Result()
+	resp.Request = req
+	return resp
+}
+
+// FastHTTPAdapter serves the request through the adapters of the fasthttp engine.
+func FastHTTPAdapter(handler engine.Handler, req *http.Request) *http.Response {
+	ctx := &fasthttp.RequestCtx{}
+	freq := &fasthttp.Request{}
+	freq.Header.SetMethod(req.Method)
+	freq.SetRequestURI(req.URL.RequestURI())
+	freq.SetHost(req.Host)
+	for key, values := range req.Header {
+		for _, value := range values {
+			freq.Header.Add(key, value)
+		}
+	}
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		freq.SetBody(body)
+	}
+	var remoteAddr net.Addr
+	if addr, err := net.ResolveTCPAddr(`tcp`, req.RemoteAddr); err == nil {
+		remoteAddr = addr
+	}
+	ctx.Init(freq, remoteAddr, nil)
+
+	s := fasthttpEngine.NewWithConfig(&engine.Config{})
+	s.SetHandler(handler)
+	s.ServeHTTP(ctx)
+
+	resp := &http.Response{
+		Status:     http.StatusText(ctx.Response.StatusCode()),
+		StatusCode: ctx.Response.StatusCode(),
+		Proto:      `HTTP/1.1`,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Request:    req,
+	}
+	ctx.Response.Header.VisitAll(func(key, value []byte) {
+		resp.Header.Add(string(key), string(value))
+	})
+	body := append([]byte(nil), ctx.Response.Body()...)
+	resp.ContentLength = int64(len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp
+}
@@ -0,0 +1,307 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/webx-top/echo"
+	"github.com/webx-top/echo/engine"
+)
+
+// DefaultHost is the host of the requests sent by `Client`, which is the same as the one of `httptest.NewRequest`.
+const DefaultHost = `example.com`
+
+// Client sends requests to an `*echo.Echo` through the adapters of an engine without network,
+// keeping the cookies across the requests like a browser.
+//
+//	client := testing.NewClient(e)
+//	client.Post(`/login`).Form(url.Values{`user`: {`admin`}}).Do().Expect(t).Status(http.StatusFound)
+//	client.Get(`/profile`).Do().Expect(t).Status(http.StatusOK).Template(`profile`)
+type Client struct {
+	Echo    *echo.Echo
+	Adapter Adapter
+	Jar     http.CookieJar
+	Header  http.Header // sent with every request
+	Host    string
+
+	mutex sync.Mutex
+}
+
+// NewClient returns a client of e which uses `StandardAdapter` by default.
+func NewClient(e *echo.Echo, adapter ...Adapter) *Client {
+	c := &Client{
+		Echo:    e,
+		Adapter: StandardAdapter,
+		Header:  http.Header{},
+		Host:    DefaultHost,
+	}
+	if len(adapter) > 0 && adapter[0] != nil {
+		c.Adapter = adapter[0]
+	}
+	c.Jar, _ = cookiejar.New(nil)
+	return c
+}
+
+func (c *Client) Get(path string) *RequestBuilder {
+	return c.Request(http.MethodGet, path)
+}
+
+func (c *Client) Head(path string) *RequestBuilder {
+	return c.Request(http.MethodHead, path)
+}
+
+func (c *Client) Post(path string) *RequestBuilder {
+	return c.Request(http.MethodPost, path)
+}
+
+func (c *Client) Put(path string) *RequestBuilder {
+	return c.Request(http.MethodPut, path)
+}
+
+func (c *Client) Patch(path string) *RequestBuilder {
+	return c.Request(http.MethodPatch, path)
+}
+
+func (c *Client) Delete(path string) *RequestBuilder {
+	return c.Request(http.MethodDelete, path)
+}
+
+func (c *Client) Options(path string) *RequestBuilder {
+	return c.Request(http.MethodOptions, path)
+}
+
+// Request returns a builder of the request. The path may contain a query string.
+func (c *Client) Request(method, path string) *RequestBuilder {
+	return &RequestBuilder{
+		client: c,
+		method: method,
+		path:   path,
+		query:  url.Values{},
+		header: c.Header.Clone(),
+	}
+}
+
+// Cookies returns the cookies of the jar sent to the path.
+func (c *Client) Cookies(path ...string) []*http.Cookie {
+	u := c.url(`/`)
+	if len(path) > 0 {
+		u = c.url(path[0])
+	}
+	return c.Jar.Cookies(u)
+}
+
+// Cookie returns the value of the cookie in the jar, or an empty string.
+func (c *Client) Cookie(name string) string {
+	for _, cookie := range c.Cookies() {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ``
+}
+
+func (c *Client) url(path string) *url.URL {
+	u, err := url.Parse(path)
+	if err != nil {
+		u = &url.URL{Path: path}
+	}
+	if len(u.Scheme) == 0 {
+		u.Scheme = `http`
+	}
+	if len(u.Host) == 0 {
+		u.Host = c.Host
+	}
+	return u
+}
+
+func (c *Client) do(req *http.Request) *Response {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.Jar != nil {
+		for _, cookie := range c.Jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+	var rendered []RenderedTemplate
+	hook := echo.RenderHook(func(name string, data interface{}) {
+		rendered = append(rendered, RenderedTemplate{Name: name, Data: data})
+	})
+	resp := c.Adapter(engine.HandlerFunc(func(req engine.Request, res engine.Response) {
+		req.SetValue(echo.RenderHookKey, hook)
+		c.Echo.ServeHTTP(req, res)
+	}), req)
+	if c.Jar != nil {
+		if cookies := resp.Cookies(); len(cookies) > 0 {
+			c.Jar.SetCookies(req.URL, cookies)
+		}
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	return &Response{Code: resp.StatusCode, Header: resp.Header, Body: body, Request: req, Rendered: rendered}
+}
+
+// RenderedTemplate is a template rendered by `Context.Render()` while serving a request.
+type RenderedTemplate struct {
+	Name string
+	Data interface{}
+}
+
+type formFile struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+// RequestBuilder builds a request of `Client`.
+type RequestBuilder struct {
+	client      *Client
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	cookies     []*http.Cookie
+	body        io.Reader
+	contentType string
+	form        url.Values
+	files       []formFile
+	remoteAddr  string
+	err         error
+}
+
+// Query adds the values of the query string.
+func (b *RequestBuilder) Query(key string, values ...string) *RequestBuilder {
+	for _, value := range values {
+		b.query.Add(key, value)
+	}
+	return b
+}
+
+// Header sets a header.
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Set(key, value)
+	return b
+}
+
+// Cookie adds a cookie besides the ones of the jar.
+func (b *RequestBuilder) Cookie(cookie *http.Cookie) *RequestBuilder {
+	b.cookies = append(b.cookies, cookie)
+	return b
+}
+
+// RemoteAddr sets the address of the client, e.g. `192.0.2.1:1234`.
+func (b *RequestBuilder) RemoteAddr(addr string) *RequestBuilder {
+	b.remoteAddr = addr
+	return b
+}
+
+// Body sets the body and its content type.
+func (b *RequestBuilder) Body(body io.Reader, contentType string) *RequestBuilder {
+	b.body = body
+	b.contentType = contentType
+	return b
+}
+
+// JSON sets the body to the JSON encoding of v.
+func (b *RequestBuilder) JSON(v interface{}) *RequestBuilder {
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Body(bytes.NewReader(data), echo.MIMEApplicationJSONCharsetUTF8)
+}
+
+// Form sets the url-encoded form values, or the fields of the multipart form if `File()` is called.
+func (b *RequestBuilder) Form(values url.Values) *RequestBuilder {
+	if b.form == nil {
+		b.form = url.Values{}
+	}
+	for key, vals := range values {
+		b.form[key] = append(b.form[key], vals...)
+	}
+	return b
+}
+
+// FormValue adds a value of the form.
+func (b *RequestBuilder) FormValue(key, value string) *RequestBuilder {
+	return b.Form(url.Values{key: {value}})
+}
+
+// File adds a file to the multipart form.
+func (b *RequestBuilder) File(field, filename string, content []byte) *RequestBuilder {
+	b.files = append(b.files, formFile{field: field, filename: filename, content: content})
+	return b
+}
+
+// Build returns the request.
+func (b *RequestBuilder) Build() (*http.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	u := b.client.url(b.path)
+	if len(b.query) > 0 {
+		query := u.Query()
+		for key, values := range b.query {
+			query[key] = append(query[key], values...)
+		}
+		u.RawQuery = query.Encode()
+	}
+	body, contentType := b.body, b.contentType
+	switch {
+	case len(b.files) > 0:
+		buf := new(bytes.Buffer)
+		w := multipart.NewWriter(buf)
+		for key, values := range b.form {
+			for _, value := range values {
+				w.WriteField(key, value)
+			}
+		}
+		for _, f := range b.files {
+			fw, err := w.CreateFormFile(f.field, f.filename)
+			if err != nil {
+				return nil, err
+			}
+			fw.Write(f.content)
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		body, contentType = buf, w.FormDataContentType()
+	case b.form != nil:
+		body, contentType = strings.NewReader(b.form.Encode()), echo.MIMEApplicationForm
+	}
+	req, err := http.NewRequest(b.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ContextWithMockTag(req.Context()))
+	req.RequestURI = u.RequestURI()
+	req.RemoteAddr = `192.0.2.1:1234`
+	if len(b.remoteAddr) > 0 {
+		req.RemoteAddr = b.remoteAddr
+	}
+	req.Header = b.header
+	if len(contentType) > 0 {
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	for _, cookie := range b.cookies {
+		req.AddCookie(cookie)
+	}
+	return req, nil
+}
+
+// Do sends the request. It panics if the request can not be built.
+func (b *RequestBuilder) Do() *Response {
+	req, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return b.client.do(req)
+}
@@ -0,0 +1,86 @@
+package testing_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/webx-top/echo"
+	te "github.com/webx-top/echo/testing"
+)
+
+type nameRenderer struct{}
+
+func (nameRenderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	_, err := io.WriteString(w, `template `+name)
+	return err
+}
+
+func (nameRenderer) RenderBy(w io.Writer, name string, content func(string) ([]byte, error), data interface{}, c echo.Context) error {
+	_, err := io.WriteString(w, `template `+name)
+	return err
+}
+
+func TestClient(t *testing.T) {
+	e := echo.New()
+	e.SetRenderer(nameRenderer{})
+	e.Post(`/login`, func(c echo.Context) error {
+		c.SetCookie(`sid`, c.Form(`user`))
+		return c.Redirect(`/me`)
+	})
+	e.Get(`/me`, func(c echo.Context) error {
+		return c.Render(`me`, c.GetCookie(`sid`))
+	})
+	e.Put(`/items/:id`, func(c echo.Context) error {
+		var item struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		if err := c.MustBind(&item); err != nil {
+			return err
+		}
+		return c.JSON(echo.H{`data`: echo.H{`id`: c.Param(`id`), `q`: c.Query(`q`), `name`: item.Name, `tags`: item.Tags}})
+	})
+	e.Post(`/upload`, func(c echo.Context) error {
+		_, fh, err := c.Request().FormFile(`file`)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(`X-Title`, c.Form(`title`))
+		return c.String(fh.Filename)
+	})
+	e.Commit()
+
+	for name, adapter := range map[string]te.Adapter{`standard`: te.StandardAdapter, `fasthttp`: te.FastHTTPAdapter} {
+		t.Run(name, func(t *testing.T) {
+			client := te.NewClient(e, adapter)
+			client.Get(`/me`).Do().Expect(t).Status(http.StatusOK).Template(`me`).TemplateData(`me`, ``)
+
+			client.Post(`/login`).Form(url.Values{`user`: {`admin`}}).Do().Expect(t).
+				Status(http.StatusFound).Header(echo.HeaderLocation, `/me`).Cookie(`sid`, `admin`)
+			assert.Equal(t, `admin`, client.Cookie(`sid`))
+			client.Get(`/me`).Do().Expect(t).Body(`template me`).TemplateData(`me`, `admin`)
+
+			client.Put(`/items/7?q=a`).Query(`q`, `b`).JSON(echo.H{`name`: `pen`, `tags`: []string{`x`, `y`}}).Do().Expect(t).
+				Status(http.StatusOK).
+				HeaderContains(echo.HeaderContentType, echo.MIMEApplicationJSON).
+				JSONPath(`data.id`, `7`).
+				JSONPath(`data.q`, `a`).
+				JSONPath(`data.tags.1`, `y`).
+				JSON(`{"data":{"id":"7","q":"a","name":"pen","tags":["x","y"]}}`)
+
+			resp := client.Post(`/upload`).FormValue(`title`, `T`).File(`file`, `a.txt`, []byte(`hello`)).Do()
+			resp.Expect(t).Status(http.StatusOK).Body(`a.txt`).Header(`X-Title`, `T`)
+			assert.Empty(t, resp.Rendered)
+
+			_, err := resp.JSONPath(`a`)
+			assert.Error(t, err)
+
+			// the renderer of the app is not replaced
+			assert.Equal(t, echo.Renderer(nameRenderer{}), e.Renderer())
+		})
+	}
+}
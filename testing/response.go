@@ -0,0 +1,192 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Response is the response of a request sent by `Client`.
+type Response struct {
+	Code     int
+	Header   http.Header
+	Body     []byte
+	Request  *http.Request
+	Rendered []RenderedTemplate // the templates rendered by `Context.Render()`
+}
+
+func (r *Response) String() string {
+	return string(r.Body)
+}
+
+// JSON decodes the body into v.
+func (r *Response) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Cookies returns the cookies set by the response.
+func (r *Response) Cookies() []*http.Cookie {
+	return (&http.Response{Header: r.Header}).Cookies()
+}
+
+// Cookie returns the cookie set by the response, or nil.
+func (r *Response) Cookie(name string) *http.Cookie {
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// JSONPath returns the value at the dot-separated path of the JSON body, e.g. `data.items.0.name`.
+// The numbers of the JSON body are decoded as `float64`.
+func (r *Response) JSONPath(path string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(r.Body, &v); err != nil {
+		return nil, err
+	}
+	return lookupPath(v, path)
+}
+
+func lookupPath(v interface{}, path string) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	for _, key := range strings.Split(path, `.`) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			val, ok := t[key]
+			if !ok {
+				return nil, fmt.Errorf(`json path %q: key %q not found`, path, key)
+			}
+			v = val
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf(`json path %q: invalid index %q`, path, key)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf(`json path %q: %q is not an object or array`, path, key)
+		}
+	}
+	return v, nil
+}
+
+// Expect returns the assertions of the response.
+func (r *Response) Expect(t assert.TestingT) *Expectation {
+	return &Expectation{t: t, r: r}
+}
+
+// Expectation asserts on a response. The failures are reported to the `assert.TestingT`
+// and the assertions continue.
+type Expectation struct {
+	t assert.TestingT
+	r *Response
+}
+
+func (e *Expectation) Response() *Response {
+	return e.r
+}
+
+func (e *Expectation) Status(code int) *Expectation {
+	assert.Equal(e.t, code, e.r.Code, `status code`)
+	return e
+}
+
+func (e *Expectation) Header(key, value string) *Expectation {
+	assert.Equal(e.t, value, e.r.Header.Get(key), `header `+key)
+	return e
+}
+
+func (e *Expectation) HeaderContains(key, substr string) *Expectation {
+	assert.Contains(e.t, e.r.Header.Get(key), substr, `header `+key)
+	return e
+}
+
+func (e *Expectation) NoHeader(key string) *Expectation {
+	assert.Empty(e.t, e.r.Header.Values(key), `header `+key)
+	return e
+}
+
+func (e *Expectation) Body(body string) *Expectation {
+	assert.Equal(e.t, body, e.r.String())
+	return e
+}
+
+func (e *Expectation) BodyContains(substr string) *Expectation {
+	assert.Contains(e.t, e.r.String(), substr)
+	return e
+}
+
+// JSON asserts that the body and expected are equal JSON documents.
+// expected can be a string, []byte or a value encoded to JSON.
+func (e *Expectation) JSON(expected interface{}) *Expectation {
+	var b []byte
+	switch v := expected.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		var err error
+		if b, err = json.Marshal(v); !assert.NoError(e.t, err) {
+			return e
+		}
+	}
+	assert.JSONEq(e.t, string(b), e.r.String())
+	return e
+}
+
+// JSONPath asserts on the value at the path of the JSON body. See: `Response.JSONPath()`.
+// expected is compared with the value after a JSON round trip, so `1` equals the decoded `float64(1)`.
+func (e *Expectation) JSONPath(path string, expected interface{}) *Expectation {
+	actual, err := e.r.JSONPath(path)
+	if !assert.NoError(e.t, err) {
+		return e
+	}
+	b, err := json.Marshal(expected)
+	if !assert.NoError(e.t, err) {
+		return e
+	}
+	var want interface{}
+	json.Unmarshal(b, &want)
+	assert.Equal(e.t, want, actual, `json path `+path)
+	return e
+}
+
+// Cookie asserts that the response sets the cookie to value.
+func (e *Expectation) Cookie(name, value string) *Expectation {
+	cookie := e.r.Cookie(name)
+	if assert.NotNil(e.t, cookie, `cookie `+name) {
+		assert.Equal(e.t, value, cookie.Value, `cookie `+name)
+	}
+	return e
+}
+
+// Template asserts that the template was rendered.
+func (e *Expectation) Template(name string) *Expectation {
+	names := make([]string, len(e.r.Rendered))
+	for i, t := range e.r.Rendered {
+		names[i] = t.Name
+	}
+	assert.Contains(e.t, names, name, `rendered templates`)
+	return e
+}
+
+// TemplateData asserts on the data of the rendered template.
+func (e *Expectation) TemplateData(name string, data interface{}) *Expectation {
+	for _, t := range e.r.Rendered {
+		if t.Name == name {
+			assert.Equal(e.t, data, t.Data, `data of template `+name)
+			return e
+		}
+	}
+	assert.Fail(e.t, `template not rendered: `+name)
+	return e
+}
@@ -60,8 +60,9 @@ func NewValidateResult() ValidateResult {
 
 type ValidatorResult struct {
 	error
-	field string
-	raw   interface{}
+	field  string
+	raw    interface{}
+	fields FieldErrors
 }
 
 func (v *ValidatorResult) Ok() bool {
@@ -102,6 +103,16 @@ func (v *ValidatorResult) SetRaw(raw interface{}) ValidateResult {
 	return v
 }
 
+// FieldErrors returns the errors of all the failing fields.
+func (v *ValidatorResult) FieldErrors() FieldErrors {
+	return v.fields
+}
+
+func (v *ValidatorResult) SetFieldErrors(fields FieldErrors) ValidateResult {
+	v.fields = fields
+	return v
+}
+
 var (
 	DefaultNopValidate     Validator = &NopValidation{}
 	defaultValidatorResult           = NewValidateResult()
@@ -159,6 +170,9 @@ func (v *Validation) Validate(i interface{}, args ...interface{}) ValidateResult
 		e.SetError(vErr)
 		e.SetField(vErr.Field)
 		e.SetRaw(v.validator.Errors)
+		if r, ok := e.(FieldErrorsResult); ok {
+			r.SetFieldErrors(NewFieldErrors(v.validator.Errors))
+		}
 		v.validator.Errors = nil
 	}
 	return e
@@ -188,6 +202,7 @@ func Validate(c Context, item interface{}, args ...interface{}) error {
 	}
 	result := c.Validator().Validate(item, args...)
 	if err := result.Unwrap(); err != nil {
+		translateValidateResult(c, result)
 		return result
 	}
 	return nil
@@ -201,6 +216,7 @@ func ValidateStruct(c Context, item interface{}, args ...interface{}) error {
 	}
 	result := c.Validator().Validate(item, args...)
 	if err := result.Unwrap(); err != nil {
+		translateValidateResult(c, result)
 		return result
 	}
 	if after, ok := item.(AfterValidate); ok {
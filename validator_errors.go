@@ -0,0 +1,143 @@
+package echo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/webx-top/validation"
+)
+
+// FieldError is the validation error of a field.
+type FieldError struct {
+	Field   string        `json:"field" xml:"field"`                       // the form name of the field, e.g. `Profile[City]`
+	Rule    string        `json:"rule,omitempty" xml:"rule,omitempty"`     // the name of the failed rule, e.g. `Required`
+	Params  []interface{} `json:"params,omitempty" xml:"params,omitempty"` // the parameters of the rule, e.g. `[1 10]` of `Range(1,10)`
+	Message string        `json:"message" xml:"message"`
+
+	tmpl string
+}
+
+// Path returns the names of the field path, the same as `FormNames(Field)`.
+func (f *FieldError) Path() []string {
+	return FormNames(f.Field)
+}
+
+func (f *FieldError) Error() string {
+	return f.Field + `: ` + f.Message
+}
+
+// FieldErrors is the list of the validation errors of all the failing fields.
+type FieldErrors []*FieldError
+
+func (f FieldErrors) Error() string {
+	messages := make([]string, len(f))
+	for i, e := range f {
+		messages[i] = e.Error()
+	}
+	return strings.Join(messages, `; `)
+}
+
+// Map returns the messages by field. The first error of a field wins.
+func (f FieldErrors) Map() map[string]string {
+	m := make(map[string]string, len(f))
+	for _, e := range f {
+		if _, ok := m[e.Field]; !ok {
+			m[e.Field] = e.Message
+		}
+	}
+	return m
+}
+
+// Get returns the first error of the field, or nil.
+func (f FieldErrors) Get(field string) *FieldError {
+	for _, e := range f {
+		if e.Field == field {
+			return e
+		}
+	}
+	return nil
+}
+
+// Translate translates the messages of the errors which come from the message templates
+// of the rules.
+func (f FieldErrors) Translate(t Translator) FieldErrors {
+	for _, e := range f {
+		if len(e.tmpl) == 0 {
+			continue
+		}
+		e.Message = t.T(e.tmpl, e.Params...)
+	}
+	return f
+}
+
+// FieldErrorsResult is implemented by the `ValidateResult` which reports all the failing fields.
+type FieldErrorsResult interface {
+	FieldErrors() FieldErrors
+	SetFieldErrors(FieldErrors) ValidateResult
+}
+
+// FieldErrorsOf returns the field errors in the chain of err.
+func FieldErrorsOf(err error) FieldErrors {
+	var fe FieldErrors
+	if errors.As(err, &fe) {
+		return fe
+	}
+	var result ValidateResult
+	if errors.As(err, &result) && !result.Ok() {
+		if r, ok := result.(FieldErrorsResult); ok {
+			if fe = r.FieldErrors(); len(fe) > 0 {
+				return fe
+			}
+		}
+		if field := result.Field(); len(field) > 0 {
+			return FieldErrors{{Field: field, Message: result.Unwrap().Error()}}
+		}
+	}
+	return nil
+}
+
+// NewFieldErrors converts the errors of the validation package.
+func NewFieldErrors(verrs []*validation.ValidationError) FieldErrors {
+	fe := make(FieldErrors, len(verrs))
+	for i, verr := range verrs {
+		fe[i] = &FieldError{
+			Field:   FormFieldName(verr.Field),
+			Rule:    verr.Name,
+			Params:  limitParams(verr.LimitValue),
+			Message: verr.Message,
+			tmpl:    verr.Tmpl,
+		}
+	}
+	return fe
+}
+
+// FormFieldName converts the struct field path to the form name, e.g. `Profile.City` to `Profile[City]`.
+func FormFieldName(structPath string) string {
+	names := strings.Split(structPath, `.`)
+	if len(names) == 1 {
+		return structPath
+	}
+	return names[0] + `[` + strings.Join(names[1:], `][`) + `]`
+}
+
+func limitParams(limit interface{}) []interface{} {
+	if limit == nil {
+		return nil
+	}
+	v := reflect.ValueOf(limit)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []interface{}{limit}
+	}
+	params := make([]interface{}, v.Len())
+	for i := range params {
+		params[i] = v.Index(i).Interface()
+	}
+	return params
+}
+
+func translateValidateResult(c Context, result ValidateResult) {
+	if r, ok := result.(FieldErrorsResult); ok {
+		r.FieldErrors().Translate(c)
+	}
+}
@@ -0,0 +1,76 @@
+package echo_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/webx-top/echo"
+	test "github.com/webx-top/echo/testing"
+)
+
+type testValidProfile struct {
+	City string `valid:"Required"`
+}
+
+type testValidUser struct {
+	Name    string `valid:"Required"`
+	Age     int    `valid:"Range(1,10)"`
+	Profile testValidProfile
+}
+
+type testTranslator struct{}
+
+func (testTranslator) T(format string, args ...interface{}) string {
+	return `zh:` + fmt.Sprintf(format, args...)
+}
+
+func (testTranslator) E(format string, args ...interface{}) error {
+	return fmt.Errorf(format, args...)
+}
+
+func (testTranslator) Lang() LangCode {
+	return NewLangCode(`zh-CN`)
+}
+
+func TestFieldErrors(t *testing.T) {
+	e := New()
+	e.SetValidator(NewValidation())
+	var err error
+	e.Post(`/`, func(c Context) error {
+		err = c.MustBindAndValidate(&testValidUser{})
+		return nil
+	})
+	e.Commit()
+	test.Request(http.MethodPost, `/?Age=20`, e, func(req *http.Request) {
+		req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	})
+	fields := FieldErrorsOf(err)
+	assert.Len(t, fields, 3)
+	assert.Equal(t, `Name`, fields[0].Field)
+	assert.Equal(t, `Required`, fields[0].Rule)
+	assert.Equal(t, `Age`, fields[1].Field)
+	assert.Equal(t, `Range`, fields[1].Rule)
+	assert.Equal(t, []interface{}{float64(1), float64(10)}, fields[1].Params)
+	assert.Equal(t, `Profile[City]`, fields[2].Field)
+	assert.Equal(t, []string{`Profile`, `City`}, fields[2].Path())
+	assert.Equal(t, map[string]string{
+		`Name`:          fields[0].Message,
+		`Age`:           fields[1].Message,
+		`Profile[City]`: fields[2].Message,
+	}, fields.Map())
+
+	fields.Translate(testTranslator{})
+	assert.True(t, strings.HasPrefix(fields[0].Message, `zh:`))
+
+	data := NewData(nil).SetError(err)
+	assert.Equal(t, `Name`, data.GetZone())
+	assert.Equal(t, fields.Map(), data.GetData())
+
+	b, _ := json.Marshal(fields)
+	assert.Contains(t, string(b), `"field":"Profile[City]"`)
+}